@@ -2,25 +2,143 @@ package env
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 // checkedValue wraps a Value and runs fn on any values passed to Set
 // before calling the underlying Value.Set.
 type checkedValue struct {
-	fn func(string) error
+	typ        string // type, if different from the underlying Value
+	fn         func(string) error
+	constraint bool // if true, fn checks a constraint rather than the type
 
 	Value
 }
 
 func (v checkedValue) Set(x string) error {
-	if err := v.fn(x); err != nil {
+	if err := v.check(x); err != nil {
 		return err
 	}
 	return v.Value.Set(x)
 }
 
+// check runs fn on x.  Errors from constraints are wrapped in a
+// constraintError.
+func (v checkedValue) check(x string) error {
+	err := v.fn(x)
+	if err != nil && v.constraint {
+		return constraintError{err}
+	}
+	return err
+}
+
+// constraintError is an error from checking a constraint set by an option
+// such as OneOf, rather than from parsing the value.
+type constraintError struct {
+	error
+}
+
+func (e constraintError) Unwrap() error {
+	return e.error
+}
+
+func (v checkedValue) Type() string {
+	if v.typ != "" {
+		return v.typ
+	}
+	if t, ok := v.Value.(Typed); ok {
+		return t.Type()
+	}
+	return "string"
+}
+
+// validate returns the error v.Set would return for x, without changing the
+// value of v (see Validator).  Values which are pointers are validated by
+// setting a shallow copy; for other Values, the previous value is restored
+// after calling Set.
+func validate(v Value, x string) error {
+	if lv, ok := v.(interface{ validate(string) error }); ok {
+		return lv.validate(x)
+	}
+	if c, ok := v.(checkedValue); ok {
+		if err := c.check(x); err != nil {
+			return err
+		}
+		return validate(c.Value, x)
+	}
+	if vv, ok := v.(Validator); ok {
+		return vv.Validate(x)
+	}
+
+	if p := reflect.ValueOf(v); p.Kind() == reflect.Ptr && !p.IsNil() {
+		c := reflect.New(p.Type().Elem())
+		c.Elem().Set(p.Elem())
+		if cv, ok := c.Interface().(Value); ok {
+			return cv.Set(x)
+		}
+	}
+
+	// Set may change the value even if it fails.
+	prev := v.String()
+	err := v.Set(x)
+	v.Set(prev)
+	return err
+}
+
+// isOneOf returns a check that x is one of values.
+func isOneOf(values []string) func(string) error {
+	return func(x string) error {
+		for _, v := range values {
+			if x == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", x, strings.Join(quoteAll(values), ", "))
+	}
+}
+
+func quoteAll(xs []string) []string {
+	out := make([]string, len(xs))
+	for i, x := range xs {
+		out[i] = strconv.Quote(x)
+	}
+	return out
+}
+
+// isMatch returns a check that x matches re.
+func isMatch(re *regexp.Regexp) func(string) error {
+	return func(x string) error {
+		if !re.MatchString(x) {
+			return fmt.Errorf("%q does not match %q", x, re.String())
+		}
+		return nil
+	}
+}
+
+// isInRange returns a check that x is a number in the range [min, max].
+// Values which are not numbers are left for the underlying Value to reject.
+func isInRange(min, max *float64) func(string) error {
+	return func(x string) error {
+		n, err := strconv.ParseFloat(x, 64)
+		if err != nil {
+			return nil
+		}
+		if min != nil && n < *min {
+			return fmt.Errorf("%v is less than minimum %v", x, *min)
+		}
+		if max != nil && n > *max {
+			return fmt.Errorf("%v is greater than maximum %v", x, *max)
+		}
+		return nil
+	}
+}
+
 // isNonEmpty checks if x is a non-empty string.
 func isNonEmpty(x string) error {
 	if x == "" {
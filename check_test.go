@@ -1,8 +1,10 @@
 package env_test
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -228,3 +230,190 @@ func TestMissingGetter(t *testing.T) {
 		t.Errorf("expected error for missing var")
 	}
 }
+
+func TestConstraints(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     env.VarOption
+		in      string
+		wantErr bool
+	}{
+		{"OneOf/valid", env.OneOf("1", "2"), "2", false},
+		{"OneOf/invalid", env.OneOf("1", "2"), "3", true},
+		{"Pattern/valid", env.Pattern(`^[0-9]$`), "7", false},
+		{"Pattern/invalid", env.Pattern(`^[0-9]$`), "17", true},
+		{"Min/valid", env.Min(1), "1", false},
+		{"Min/invalid", env.Min(1), "0", true},
+		{"Max/valid", env.Max(10), "10", false},
+		{"Max/invalid", env.Max(10), "11", true},
+		{"Max/not a number", env.Max(10), "a", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vs := env.NewVarSet("")
+			vs.Int("INT", "int test", tt.opt)
+
+			if err := vs.Parse(testGetter{"INT": tt.in}); (err != nil) != tt.wantErr {
+				t.Errorf("vs.Parse() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMinMaxRequireNumeric(t *testing.T) {
+	tests := []struct {
+		name string
+		def  func(vs *env.VarSet)
+	}{
+		{"Min/duration", func(vs *env.VarSet) { vs.Duration("TIMEOUT", "timeout test", env.Min(1)) }},
+		{"Max/string", func(vs *env.VarSet) { vs.String("NAME", "name test", env.Max(10)) }},
+		{"Max/bindaddr", func(vs *env.VarSet) { vs.BindAddr("LISTEN", "listen test", env.Default(":80"), env.Max(10)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("expected panic using Min or Max on a variable which isn't numeric")
+				}
+			}()
+			tt.def(env.NewVarSet(""))
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	vs := env.NewVarSet("")
+	workers := vs.Int("WORKERS", "workers test", env.Default("4"))
+	timeout := vs.Duration("TIMEOUT", "timeout test", env.Optional())
+	level := vs.String("LEVEL", "level test", env.Default("trace"), env.OneOf("debug", "info"))
+
+	var x *env.Var
+	vs.Visit(func(v *env.Var) {
+		if v.Name == "TIMEOUT" {
+			x = v
+		}
+	})
+	if x.Required() {
+		t.Errorf("TIMEOUT: Required() = true, expected false")
+	}
+	if got := x.Type(); got != "duration" {
+		t.Errorf("TIMEOUT: Type() = %q, expected %q", got, "duration")
+	}
+
+	err := vs.Parse(testGetter{})
+	if err == nil || !strings.Contains(err.Error(), "could not set env LEVEL to default") {
+		t.Errorf("vs.Parse() = %v, expected invalid default error", err)
+	}
+	if *workers != 4 || *timeout != 0 {
+		t.Errorf("vs.Parse() set (%d, %v), expected (4, 0s)", *workers, *timeout)
+	}
+
+	if err := vs.Parse(testGetter{"WORKERS": "8", "LEVEL": "info"}); err != nil {
+		t.Errorf("vs.Parse() = %v, expected nil error", err)
+	}
+	if *workers != 8 || *level != "info" {
+		t.Errorf("vs.Parse() set (%d, %q), expected (8, \"info\")", *workers, *level)
+	}
+}
+
+func TestExample(t *testing.T) {
+	vs := env.NewVarSet("")
+	workers := vs.Int("WORKERS", "workers test", env.Example("8"), env.Min(1))
+	listen := vs.BindAddr("LISTEN", "listen test", env.Example(":8080"))
+	vs.String("LEVEL", "level test")
+
+	if err := vs.CheckExamples(); err != nil {
+		t.Errorf("vs.CheckExamples() = %v, expected nil error", err)
+	}
+	if *workers != 0 || *listen != "" {
+		t.Errorf("vs.CheckExamples() set (%d, %q), expected values unchanged", *workers, *listen)
+	}
+
+	vs.Int("RETRIES", "retries test", env.Example("three"))
+	vs.String("MODE", "mode test", env.Example("fast"), env.OneOf("slow"))
+	err := vs.CheckExamples()
+	es, ok := err.(env.Errors)
+	if !ok || len(es) != 2 {
+		t.Fatalf("vs.CheckExamples() = %v, expected 2 errors", err)
+	}
+	if !strings.Contains(es[0].Error(), "invalid example for env RETRIES") || !strings.Contains(es[1].Error(), "invalid example for env MODE") {
+		t.Errorf("vs.CheckExamples() = %v, expected errors for RETRIES and MODE", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var logs testLogger
+	vs := env.NewVarSet("")
+	vs.SetLogger(&logs)
+	workers := vs.Int("WORKERS", "workers test", env.Max(8), env.Deprecated("use THREADS"))
+	name := vs.String("NAME", "name test", env.Default("default"))
+	vs.Duration("TIMEOUT", "timeout test")
+
+	g := testGetter{"WORKERS": "16", "TIMEOUT": "soon"}
+	checkErr := vs.Check(g)
+	if *workers != 0 || *name != "" {
+		t.Errorf("vs.Check() set (%d, %q), expected values unchanged", *workers, *name)
+	}
+	if len(logs) != 0 {
+		t.Errorf("vs.Check() logged %q, expected nothing", logs)
+	}
+	vs.Visit(func(v *env.Var) {
+		if v.Source() != "" {
+			t.Errorf("%v: Source() = %q after vs.Check(), expected none", v.Name, v.Source())
+		}
+	})
+
+	parseErr := vs.Parse(g)
+	if checkErr == nil || parseErr == nil || checkErr.Error() != parseErr.Error() {
+		t.Errorf("vs.Check() = %v, expected the same as vs.Parse() = %v", checkErr, parseErr)
+	}
+
+	if err := vs.Check(testGetter{"WORKERS": "4", "TIMEOUT": "1s"}); err != nil {
+		t.Errorf("vs.Check() = %v, expected nil error", err)
+	}
+	if *name != "default" {
+		t.Errorf("vs.Check() set NAME to %q, expected it unchanged", *name)
+	}
+}
+
+// listValue is a comma-separated list stored in a slice it refers to, which
+// Set changes even when it fails.
+type listValue struct{ p *[]string }
+
+func (v listValue) String() string { return strings.Join(*v.p, ",") }
+
+func (v listValue) Set(x string) error {
+	*v.p = strings.Split(x, ",")
+	for _, s := range *v.p {
+		if s == "" {
+			return errors.New("empty element")
+		}
+	}
+	return nil
+}
+
+// validatedListValue is a listValue which implements env.Validator.
+type validatedListValue struct{ listValue }
+
+func (v validatedListValue) Validate(x string) error {
+	return listValue{p: new([]string)}.Set(x)
+}
+
+func TestCheckCustomValue(t *testing.T) {
+	hosts, ports := []string{"orig"}, []string{"80"}
+	vs := env.NewVarSet("p")
+	vs.Var(validatedListValue{listValue{&hosts}}, "HOSTS", "hosts test")
+	vs.Var(listValue{&ports}, "PORTS", "ports test")
+
+	if err := vs.Check(testGetter{"P_HOSTS": "a,b", "P_PORTS": "1,2"}); err != nil {
+		t.Errorf("vs.Check() = %v, expected nil error", err)
+	}
+	if err := vs.Check(testGetter{"P_HOSTS": "a,,b", "P_PORTS": "1,,2"}); err == nil {
+		t.Error("vs.Check() = nil, expected errors for empty elements")
+	}
+	if !reflect.DeepEqual(hosts, []string{"orig"}) || !reflect.DeepEqual(ports, []string{"80"}) {
+		t.Errorf("vs.Check() changed the lists to %q and %q, expected them unchanged", hosts, ports)
+	}
+}
@@ -0,0 +1,113 @@
+package env
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+// Kinds of Change.
+const (
+	Added   ChangeKind = iota // set in the after environment only
+	Removed                   // set in the before environment only
+	Changed                   // set in both environments, with different values
+	Unknown                   // has the prefix of the set, but is not a variable
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	case Unknown:
+		return "unknown"
+	}
+	return "ChangeKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Change describes a difference between two environments.
+type Change struct {
+	Kind ChangeKind
+	Name string
+	Var  *Var // variable, or nil if Kind is Unknown
+
+	Old, New       string // values in the before and after environments
+	HasOld, HasNew bool   // whether the variable is set in each environment
+}
+
+// Diff compares the values of the variables in the set between the before
+// and after environments, returning the changes in the order in which the
+// variables were defined.
+//
+// If either environment is a Lister, then its variables which have the
+// prefix of the set but are not read by any variable (or alias) in the set
+// are also returned, sorted by name, with Kind Unknown.
+func (v *VarSet) Diff(before, after Getter) []Change {
+	var changes []Change
+	for _, x := range v.vars {
+		o, hasOld := x.Get(before)
+		n, hasNew := x.Get(after)
+		c := Change{Name: x.Name, Var: x, Old: o, New: n, HasOld: hasOld, HasNew: hasNew}
+		switch {
+		case hasNew && !hasOld:
+			c.Kind = Added
+		case hasOld && !hasNew:
+			c.Kind = Removed
+		case hasOld && hasNew && o != n:
+			c.Kind = Changed
+		default:
+			continue
+		}
+		changes = append(changes, c)
+	}
+
+	unknown := make(map[string]bool)
+	for _, g := range []Getter{before, after} {
+		for _, name := range v.unknownNames(g) {
+			unknown[name] = true
+		}
+	}
+	names := make([]string, 0, len(unknown))
+	for name := range unknown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		o, hasOld := before.Get(name)
+		n, hasNew := after.Get(name)
+		changes = append(changes, Change{Kind: Unknown, Name: name, Old: o, New: n, HasOld: hasOld, HasNew: hasNew})
+	}
+	return changes
+}
+
+// unknownNames returns the names of the variables in g (if it is a Lister)
+// which have the prefix of the set, but are not read by any of its
+// variables.  Nothing is returned if the set has no prefix.
+func (v *VarSet) unknownNames(g Getter) []string {
+	l, ok := g.(Lister)
+	if !ok || v.prefix == "" {
+		return nil
+	}
+
+	known := make(map[string]bool, len(v.vars))
+	for _, x := range v.vars {
+		known[x.Name] = true
+		for _, a := range x.Aliases {
+			known[a.Name] = true
+		}
+	}
+
+	var names []string
+	for _, name := range l.Names() {
+		if strings.HasPrefix(name, v.prefix+v.sep) && !known[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
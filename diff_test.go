@@ -0,0 +1,48 @@
+package env_test
+
+import (
+	"reflect"
+	"testing"
+
+	"code.sajari.com/env"
+)
+
+func TestDiff(t *testing.T) {
+	vs := env.NewVarSet("svc")
+	vs.String("SAME", "same")
+	vs.String("ADDED", "added")
+	vs.String("REMOVED", "removed")
+	vs.String("CHANGED", "changed")
+	vs.Int("PORT", "port", env.Aliases("PORT"))
+
+	before := env.Map{
+		"SVC_SAME":    "1",
+		"SVC_REMOVED": "1",
+		"SVC_CHANGED": "1",
+		"PORT":        "80",
+		"SVC_TYPO":    "1",
+		"OTHER":       "1",
+	}
+	after := env.Map{
+		"SVC_SAME":    "1",
+		"SVC_ADDED":   "1",
+		"SVC_CHANGED": "2",
+		"SVC_PORT":    "80",
+		"SVC_WORKRES": "4",
+	}
+
+	var got []string
+	for _, c := range vs.Diff(before, after) {
+		got = append(got, c.Kind.String()+" "+c.Name+" "+c.Old+" "+c.New)
+	}
+	want := []string{
+		"added SVC_ADDED  1",
+		"removed SVC_REMOVED 1 ",
+		"changed SVC_CHANGED 1 2",
+		"unknown SVC_TYPO 1 ",
+		"unknown SVC_WORKRES  4",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("vs.Diff() = %q, expected %q", got, want)
+	}
+}
@@ -0,0 +1,179 @@
+package env
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DocsFormat is a format of the documentation written by WriteDocs.
+type DocsFormat string
+
+// Formats supported by WriteDocs.
+const (
+	Markdown DocsFormat = "markdown"
+	HTML     DocsFormat = "html"
+)
+
+// docsColumns are the column headings of the table written by WriteDocs.
+var docsColumns = []string{"Name", "Type", "Default", "Required", "Constraints", "Description"}
+
+// WriteDocs writes a reference table of the variables in the set to w, in
+// the order in which they were defined.  The table includes the name, type,
+// default, constraints and usage of each variable, along with any aliases
+// and deprecation notes.
+func (v *VarSet) WriteDocs(w io.Writer, format DocsFormat) error {
+	var code, escape func(string) string
+	switch format {
+	case Markdown:
+		code, escape = markdownCode, markdownEscape
+	case HTML:
+		code, escape = htmlCode, html.EscapeString
+	default:
+		return fmt.Errorf("unknown docs format %q", format)
+	}
+
+	var rows [][]string
+	v.Visit(func(x *Var) {
+		rows = append(rows, docsRow(x, code, escape))
+	})
+
+	ew := &errWriter{w: w}
+	if format == Markdown {
+		writeMarkdownTable(ew, rows)
+	} else {
+		writeHTMLTable(ew, rows)
+	}
+	return ew.err
+}
+
+// docsRow returns the cells of the row describing x.  Cells are already
+// formatted using code and escape.
+func docsRow(x *Var, code, escape func(string) string) []string {
+	var def string
+	if x.Default != "" {
+		def = code(x.Default)
+	}
+	required := "no"
+	if x.Required() {
+		required = "yes"
+	}
+
+	var cs []string
+	c := x.Constraints
+	if len(c.Enum) > 0 {
+		vs := make([]string, len(c.Enum))
+		for i, e := range c.Enum {
+			vs[i] = code(e)
+		}
+		cs = append(cs, "one of "+strings.Join(vs, ", "))
+	}
+	if c.Pattern != "" {
+		cs = append(cs, "matches "+code(c.Pattern))
+	}
+	if c.Min != nil {
+		cs = append(cs, escape(">= "+strconv.FormatFloat(*c.Min, 'f', -1, 64)))
+	}
+	if c.Max != nil {
+		cs = append(cs, escape("<= "+strconv.FormatFloat(*c.Max, 'f', -1, 64)))
+	}
+
+	desc := []string{escape(x.Usage)}
+	if x.Secret {
+		desc = append(desc, "Secret.")
+	}
+	if x.Reloadable {
+		desc = append(desc, "Reloadable.")
+	}
+	for _, a := range x.Aliases {
+		if a.Name == x.Name {
+			continue
+		}
+		if a.Deprecated != "" {
+			desc = append(desc, "Also read from "+code(a.Name)+" (deprecated: "+escape(a.Deprecated)+").")
+		} else {
+			desc = append(desc, "Also read from "+code(a.Name)+".")
+		}
+	}
+	if x.Example != "" {
+		desc = append(desc, "Example: "+code(x.Example)+".")
+	}
+	if x.Deprecated != "" {
+		desc = append(desc, "Deprecated: "+escape(x.Deprecated))
+	}
+
+	return []string{
+		code(x.Name),
+		escape(x.Type()),
+		def,
+		required,
+		strings.Join(cs, ", "),
+		strings.TrimSpace(strings.Join(desc, " ")),
+	}
+}
+
+func writeMarkdownTable(w io.Writer, rows [][]string) {
+	fmt.Fprintf(w, "| %v |\n", strings.Join(docsColumns, " | "))
+	fmt.Fprintf(w, "|%v\n", strings.Repeat(" --- |", len(docsColumns)))
+	for _, row := range rows {
+		fmt.Fprintf(w, "| %v |\n", strings.Join(row, " | "))
+	}
+}
+
+// markdownEscape escapes characters in x which would otherwise break
+// a table cell or be interpreted as Markdown.
+var markdownEscape = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;", ">", "&gt;", "\n", "<br>",
+).Replace
+
+// markdownCode formats x as inline code.  Backslashes are literal in code
+// spans, so pipes are replaced by their HTML entity instead.
+func markdownCode(x string) string {
+	x = strings.NewReplacer("|", "&#124;", "\n", " ").Replace(x)
+	fence := "`"
+	for strings.Contains(x, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(x, "`") || strings.HasSuffix(x, "`") {
+		x = " " + x + " "
+	}
+	return fence + x + fence
+}
+
+func writeHTMLTable(w io.Writer, rows [][]string) {
+	fmt.Fprintf(w, "<table>\n<thead>\n<tr>")
+	for _, c := range docsColumns {
+		fmt.Fprintf(w, "<th>%v</th>", c)
+	}
+	fmt.Fprintf(w, "</tr>\n</thead>\n<tbody>\n")
+	for _, row := range rows {
+		fmt.Fprintf(w, "<tr>")
+		for _, c := range row {
+			fmt.Fprintf(w, "<td>%v</td>", c)
+		}
+		fmt.Fprintf(w, "</tr>\n")
+	}
+	fmt.Fprintf(w, "</tbody>\n</table>\n")
+}
+
+func htmlCode(x string) string {
+	return "<code>" + html.EscapeString(x) + "</code>"
+}
+
+// errWriter is a Writer which records the first error from w, and discards
+// all writes after it.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return len(p), nil
+	}
+	n, err := w.w.Write(p)
+	w.err = err
+	return n, err
+}
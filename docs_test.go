@@ -0,0 +1,59 @@
+package env_test
+
+import (
+	"bytes"
+	"testing"
+
+	"code.sajari.com/env"
+)
+
+func TestWriteDocs(t *testing.T) {
+	vs := env.NewVarSet("svc")
+	vs.BindAddr("LISTEN", "bind address for gRPC server")
+	vs.Int("WORKERS", "number of parallel_workers", env.Default("4"), env.Min(1), env.Max(64))
+	vs.String("LEVEL", "log level", env.OneOf("debug", "info"), env.Optional())
+	vs.String("KEY", "API key | token", env.Secret(), env.DeprecatedAlias("API_KEY", "use SVC_KEY"))
+	vs.Bool("DEBUG", "debug mode", env.Deprecated("use SVC_LEVEL"), env.Optional())
+
+	tests := []struct {
+		format env.DocsFormat
+		want   string
+	}{
+		{env.Markdown, "" +
+			"| Name | Type | Default | Required | Constraints | Description |\n" +
+			"| --- | --- | --- | --- | --- | --- |\n" +
+			"| `SVC_LISTEN` | bindaddr |  | yes |  | bind address for gRPC server |\n" +
+			"| `SVC_WORKERS` | int | `4` | no | &gt;= 1, &lt;= 64 | number of parallel\\_workers |\n" +
+			"| `SVC_LEVEL` | string |  | no | one of `debug`, `info` | log level |\n" +
+			"| `SVC_KEY` | string |  | yes |  | API key \\| token Secret. Also read from `API_KEY` (deprecated: use SVC\\_KEY). |\n" +
+			"| `SVC_DEBUG` | bool |  | no |  | debug mode Deprecated: use SVC\\_LEVEL |\n",
+		},
+		{env.HTML, "" +
+			"<table>\n<thead>\n" +
+			"<tr><th>Name</th><th>Type</th><th>Default</th><th>Required</th><th>Constraints</th><th>Description</th></tr>\n" +
+			"</thead>\n<tbody>\n" +
+			"<tr><td><code>SVC_LISTEN</code></td><td>bindaddr</td><td></td><td>yes</td><td></td><td>bind address for gRPC server</td></tr>\n" +
+			"<tr><td><code>SVC_WORKERS</code></td><td>int</td><td><code>4</code></td><td>no</td><td>&gt;= 1, &lt;= 64</td><td>number of parallel_workers</td></tr>\n" +
+			"<tr><td><code>SVC_LEVEL</code></td><td>string</td><td></td><td>no</td><td>one of <code>debug</code>, <code>info</code></td><td>log level</td></tr>\n" +
+			"<tr><td><code>SVC_KEY</code></td><td>string</td><td></td><td>yes</td><td></td><td>API key | token Secret. Also read from <code>API_KEY</code> (deprecated: use SVC_KEY).</td></tr>\n" +
+			"<tr><td><code>SVC_DEBUG</code></td><td>bool</td><td></td><td>no</td><td></td><td>debug mode Deprecated: use SVC_LEVEL</td></tr>\n" +
+			"</tbody>\n</table>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := vs.WriteDocs(&buf, tt.format); err != nil {
+				t.Fatalf("vs.WriteDocs() = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("vs.WriteDocs() wrote\n%v\nexpected\n%v", got, tt.want)
+			}
+		})
+	}
+
+	if err := vs.WriteDocs(&bytes.Buffer{}, "pdf"); err == nil {
+		t.Errorf("vs.WriteDocs() = nil, expected error for unknown format")
+	}
+}
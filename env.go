@@ -5,6 +5,7 @@ package env // import "code.sajari.com/env"
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/url"
 	"os"
 	"path"
@@ -18,6 +19,63 @@ type Var struct {
 	Name  string // name
 	Usage string // help message
 	Value Value  // value as set
+
+	Aliases    []Alias // alternative names, read if Name is not set
+	Deprecated string  // if non-empty, the variable is deprecated for this reason
+
+	Optional    bool        // if true, the variable does not need to be set
+	Default     string      // if non-empty, value used if the variable is not set
+	Example     string      // if non-empty, an example value for documentation and dumps
+	Constraints Constraints // constraints on the value, see Constraints
+	Secret      bool        // if true, the value is sensitive and should not be displayed
+	Reloadable  bool        // if true, the value may be changed while the program is running
+
+	key    string  // name as passed to VarSet.Var
+	set    *VarSet // set the variable was defined in
+	source string  // name the value was read from by Parse, see Source
+}
+
+// Alias is an alternative name for a variable.
+type Alias struct {
+	Name       string // full name, the prefix is not applied
+	Deprecated string // if non-empty, the alias is deprecated for this reason
+}
+
+// Get retrieves the value of the variable from g.  If Name is not set, then
+// each of the aliases are tried in turn.
+func (x *Var) Get(g Getter) (string, bool) {
+	z, _, ok := lookup(g, x)
+	return z, ok
+}
+
+// Source returns the name of the variable or alias that the value was read
+// from by the last call to Parse, or "" if it was not set (in which case the
+// default, if any, was used).  Var.Reload records the source of the change
+// instead.
+func (x *Var) Source() string {
+	return x.source
+}
+
+// Required reports whether the variable must be set, i.e. it is not optional
+// and has no default.
+func (x *Var) Required() bool {
+	return !x.Optional && x.Default == ""
+}
+
+// Validate returns the error Value.Set would return for z, without changing
+// the value of the variable.  This is only guaranteed for the Values defined
+// in this package and Values which implement Validator; see Validator.
+func (x *Var) Validate(z string) error {
+	return validate(x.Value, z)
+}
+
+// Type returns the type of the variable's value (see Typed), or "string" if
+// it doesn't describe its type.
+func (x *Var) Type() string {
+	if t, ok := x.Value.(Typed); ok {
+		return t.Type()
+	}
+	return "string"
 }
 
 // Value is the interface to the dynamic value stored in Var.
@@ -30,6 +88,27 @@ type Value interface {
 	Set(string) error
 }
 
+// Typed is an optional interface implemented by Values which describe the
+// type of value they store.  The Values defined in this package have the
+// types "string", "int", "int64", "float32", "float64", "bool", "duration",
+// "url", "bindaddr", "dialaddr" and "path".
+type Typed interface {
+	Type() string
+}
+
+// Validator is an optional interface implemented by Values which can check
+// a value without setting it, used by Var.Validate and VarSet.Check.  Other
+// Values are validated by calling Set on a shallow copy of the Value (if it
+// is a pointer) or by calling Set and then restoring the previous value,
+// which changes any data the Value refers to, such as the slice in
+//
+//	type list struct{ p *[]string }
+//
+// so such Values should implement Validator.
+type Validator interface {
+	Validate(x string) error
+}
+
 type stringValue string
 
 func newStringValue(x string, p *string) *stringValue {
@@ -46,6 +125,8 @@ func (v *stringValue) String() string {
 	return string(*v)
 }
 
+func (v *stringValue) Type() string { return "string" }
+
 type intValue int
 
 func newIntValue(x int, p *int) *intValue {
@@ -68,6 +149,8 @@ func (v *intValue) String() string {
 	return strconv.Itoa(int(*v))
 }
 
+func (v *intValue) Type() string { return "int" }
+
 type int64Value int64
 
 func newInt64Value(x int64, p *int64) *int64Value {
@@ -90,6 +173,8 @@ func (v *int64Value) String() string {
 	return strconv.FormatInt(int64(*v), 10)
 }
 
+func (v *int64Value) Type() string { return "int64" }
+
 type float32Value float32
 
 func newFloat32Value(x float32, p *float32) *float32Value {
@@ -112,6 +197,8 @@ func (v *float32Value) String() string {
 	return strconv.FormatFloat(float64(*v), 'g', -1, 32)
 }
 
+func (v *float32Value) Type() string { return "float32" }
+
 type float64Value float64
 
 func newFloat64Value(x float64, p *float64) *float64Value {
@@ -134,6 +221,8 @@ func (v *float64Value) String() string {
 	return strconv.FormatFloat(float64(*v), 'g', -1, 64)
 }
 
+func (v *float64Value) Type() string { return "float64" }
+
 type durationValue time.Duration
 
 func newDurationValue(x time.Duration, p *time.Duration) *durationValue {
@@ -151,6 +240,8 @@ func (v *durationValue) String() string {
 	return time.Duration(*v).String()
 }
 
+func (v *durationValue) Type() string { return "duration" }
+
 type boolValue bool
 
 func newBoolValue(x bool, p *bool) *boolValue {
@@ -173,6 +264,8 @@ func (v *boolValue) String() string {
 	return strconv.FormatBool(bool(*v))
 }
 
+func (v *boolValue) Type() string { return "bool" }
+
 type urlValue url.URL
 
 func newURLValue(x url.URL, p *url.URL) *urlValue {
@@ -197,33 +290,103 @@ func (v *urlValue) String() string {
 	return u.String()
 }
 
+func (v *urlValue) Type() string { return "url" }
+
 // NewVarSet creates a new variable set with given name.
 //
 // If name is non-empty, then all variables will have a strings.ToUpper(name)+"_"
-// prefix.
+// prefix.  See SetPrefix and SetSeparator to change this.
 func NewVarSet(name string) *VarSet {
 	return &VarSet{
 		name:   name,
-		prefix: strings.Replace(strings.ToUpper(name), "-", "_", -1),
+		prefix: prefixName(name),
+		sep:    "_",
 	}
 }
 
+// prefixName converts a variable set name into a variable prefix.
+func prefixName(name string) string {
+	return strings.Replace(strings.ToUpper(name), "-", "_", -1)
+}
+
 // VarSet contains a set of variables.
 type VarSet struct {
 	name   string
 	prefix string
+	sep    string
+
+	// fixed is set if the prefix was set by SetPrefix, rather than
+	// derived from the name of the set and its parent.
+	fixed bool
+
+	// unprefixed contains the variable names which are also read
+	// without a prefix (see AliasUnprefixed).
+	unprefixed map[string]bool
+
+	parent *VarSet
+	subs   []*VarSet
 
+	// vars contains all variables defined in this set and its
+	// children, in the order in which they were defined.
 	vars []*Var
+
+	log    Logger
+	strict bool
+	fpKey  []byte // see SetFingerprintKey
+}
+
+// Sub returns a child variable set with the given name.
+//
+// Variables defined on the child have the parent's prefix followed by
+// strings.ToUpper(name)+"_", and are included when the parent (or any of its
+// ancestors) is visited or parsed.  Calling Sub again with the same name
+// returns the same child.
+func (v *VarSet) Sub(name string) *VarSet {
+	for _, s := range v.subs {
+		if s.name == name {
+			return s
+		}
+	}
+
+	s := &VarSet{
+		name:   name,
+		prefix: v.join(prefixName(name)),
+		sep:    v.sep,
+		parent: v,
+	}
+	v.subs = append(v.subs, s)
+	return s
 }
 
-// Var defines a variable with the specified name and usage string.
-func (v *VarSet) Var(value Value, name, usage string) {
-	var prefix string
-	if v.prefix != "" {
-		prefix = v.prefix + "_"
+// Parent returns the parent of the variable set, or nil if it was not
+// created by Sub.
+func (v *VarSet) Parent() *VarSet {
+	return v.parent
+}
+
+// join returns name with the prefix of v applied.
+func (v *VarSet) join(name string) string {
+	if v.prefix == "" {
+		return name
+	}
+	return v.prefix + v.sep + name
+}
+
+// Var defines a variable with the specified name, usage string and options.
+func (v *VarSet) Var(value Value, name, usage string, opts ...VarOption) {
+	x := &Var{Value: value, Name: v.join(name), Usage: usage, key: name, set: v}
+	if v.unprefixed[name] {
+		x.Aliases = append(x.Aliases, Alias{Name: name})
+	}
+	for _, opt := range opts {
+		opt(x)
+	}
+	if _, ok := x.Value.(reloadableValue); x.Reloadable && !ok {
+		panic(fmt.Sprintf("env: %v is reloadable, so must be defined using NewLive", x.Name))
+	}
+	for s := v; s != nil; s = s.parent {
+		s.vars = append(s.vars, x)
 	}
-	x := &Var{Value: value, Name: prefix + name, Usage: usage}
-	v.vars = append(v.vars, x)
 }
 
 // Name is the name of the variable set.
@@ -236,7 +399,110 @@ func (v *VarSet) Prefix() string {
 	return v.prefix
 }
 
+// SetPrefix sets the prefix applied to all variables in the set, replacing
+// the one derived from its name.  An empty prefix disables prefixing.
+//
+// Variables which have already been defined are renamed, as are the variables
+// in child sets (see Sub) which don't have their own prefix set.
+func (v *VarSet) SetPrefix(prefix string) {
+	v.prefix = prefix
+	v.fixed = true
+	v.rename()
+}
+
+// SetPrefixFromEnv calls SetPrefix with the value of the environment
+// variable key, if it is set.
+func (v *VarSet) SetPrefixFromEnv(key string) {
+	if prefix, ok := os.LookupEnv(key); ok {
+		v.SetPrefix(prefix)
+	}
+}
+
+// SetSeparator sets the separator placed between the prefix and variable
+// names in the set and its children.  The default is "_".
+func (v *VarSet) SetSeparator(sep string) {
+	v.setSeparator(sep)
+	v.rename()
+}
+
+func (v *VarSet) setSeparator(sep string) {
+	v.sep = sep
+	for _, s := range v.subs {
+		s.setSeparator(sep)
+	}
+}
+
+// SetLogger sets the logger used to report warnings, such as the use of
+// deprecated variables, from Parse.  Child sets use the logger of their
+// parent unless they have their own.  If no logger is set, warnings are
+// written to the standard logger of the log package.
+func (v *VarSet) SetLogger(l Logger) {
+	v.log = l
+}
+
+func (v *VarSet) logger() Logger {
+	for s := v; s != nil; s = s.parent {
+		if s.log != nil {
+			return s.log
+		}
+	}
+	return stdLogger{}
+}
+
+// Logger is the interface used to report warnings.  It is implemented by
+// *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+type stdLogger struct{}
+
+func (stdLogger) Printf(format string, v ...interface{}) { log.Printf(format, v...) }
+
+// rename updates the prefixes of child sets and the names of all variables
+// after a change to the prefix or separator of v.
+func (v *VarSet) rename() {
+	v.renameSubs()
+	for _, x := range v.vars {
+		x.Name = x.set.join(x.key)
+	}
+}
+
+func (v *VarSet) renameSubs() {
+	for _, s := range v.subs {
+		if !s.fixed {
+			s.prefix = v.join(prefixName(s.name))
+		}
+		s.renameSubs()
+	}
+}
+
+// AliasUnprefixed makes the variables in the set with the given names also
+// readable without the prefix, for well-known names such as PORT or
+// DATABASE_URL which are often set by the platform running the process.
+// The prefixed name takes precedence if both are set.
+//
+// Applies to variables which have already been defined, and those defined
+// afterwards.
+func (v *VarSet) AliasUnprefixed(names ...string) {
+	if v.unprefixed == nil {
+		v.unprefixed = make(map[string]bool, len(names))
+	}
+	for _, name := range names {
+		if v.unprefixed[name] {
+			continue
+		}
+		v.unprefixed[name] = true
+		for _, x := range v.vars {
+			if x.set == v && x.key == name {
+				x.Aliases = append(x.Aliases, Alias{Name: name})
+			}
+		}
+	}
+}
+
 // Visit visits the variables in the order in which they were defined, calling fn for each.
+// Variables defined in child sets (see Sub) are included.
 func (v *VarSet) Visit(fn func(v *Var)) {
 	for _, x := range v.vars {
 		fn(x)
@@ -245,115 +511,118 @@ func (v *VarSet) Visit(fn func(v *Var)) {
 
 // String defines a string variable with specified name, usage string and validation checks.
 // The return value is the address of a string variable that stores the value of the variable.
-func (v *VarSet) String(name string, usage string) *string {
+func (v *VarSet) String(name, usage string, opts ...VarOption) *string {
 	p := new(string)
-	v.Var(newStringValue("", p), name, usage)
+	v.Var(newStringValue("", p), name, usage, opts...)
 	return p
 }
 
 // StringRequired defines a required string variable with specified name and usage string.
 // The return value is the address of a string variable that stores the value of the variable.
-func (v *VarSet) StringRequired(name string, usage string) *string {
+func (v *VarSet) StringRequired(name, usage string, opts ...VarOption) *string {
 	p := new(string)
 	v.Var(checkedValue{
 		fn:    isNonEmpty,
 		Value: newStringValue("", p),
-	}, name, usage)
+	}, name, usage, opts...)
 	return p
 }
 
 // Int defines an int variable with specified name, usage string and validation checks.
 // The return value is the address of an int variable that stores the value of the variable.
-func (v *VarSet) Int(name string, usage string) *int {
+func (v *VarSet) Int(name, usage string, opts ...VarOption) *int {
 	p := new(int)
-	v.Var(newIntValue(0, p), name, usage)
+	v.Var(newIntValue(0, p), name, usage, opts...)
 	return p
 }
 
 // Int64 defines an int64 variable with specified name, usage string and validation checks.
 // The return value is the address of an int variable that stores the value of the variable.
-func (v *VarSet) Int64(name string, usage string) *int64 {
+func (v *VarSet) Int64(name, usage string, opts ...VarOption) *int64 {
 	p := new(int64)
-	v.Var(newInt64Value(0, p), name, usage)
+	v.Var(newInt64Value(0, p), name, usage, opts...)
 	return p
 }
 
 // Float32 defines an float32 variable with specified name, usage string and validation checks.
 // The return value is the address of an float32 variable that stores the value of the variable.
-func (v *VarSet) Float32(name string, usage string) *float32 {
+func (v *VarSet) Float32(name, usage string, opts ...VarOption) *float32 {
 	p := new(float32)
-	v.Var(newFloat32Value(0, p), name, usage)
+	v.Var(newFloat32Value(0, p), name, usage, opts...)
 	return p
 }
 
 // Float64 defines an float64 variable with specified name, usage string and validation checks.
 // The return value is the address of an float64 variable that stores the value of the variable.
-func (v *VarSet) Float64(name string, usage string) *float64 {
+func (v *VarSet) Float64(name, usage string, opts ...VarOption) *float64 {
 	p := new(float64)
-	v.Var(newFloat64Value(0, p), name, usage)
+	v.Var(newFloat64Value(0, p), name, usage, opts...)
 	return p
 }
 
 // Bool defines a bool variable with specified name, usage string and validation checks.
 // The return value is the address of a bool variable that stores the value of the variable.
-func (v *VarSet) Bool(name string, usage string) *bool {
+func (v *VarSet) Bool(name, usage string, opts ...VarOption) *bool {
 	p := new(bool)
-	v.Var(newBoolValue(false, p), name, usage)
+	v.Var(newBoolValue(false, p), name, usage, opts...)
 	return p
 }
 
 // Duration defines a time.Duration variable with specified name, usage string and validation checks.
 // The return value is the address of a time.Duration variable that stores the value of the variable.
-func (v *VarSet) Duration(name string, usage string) *time.Duration {
+func (v *VarSet) Duration(name, usage string, opts ...VarOption) *time.Duration {
 	p := new(time.Duration)
-	v.Var(newDurationValue(time.Duration(0), p), name, usage)
+	v.Var(newDurationValue(time.Duration(0), p), name, usage, opts...)
 	return p
 }
 
 // BindAddr defines a string variable with specified name, usage string validated as a
 // bind address (host:port).
 // The return value is the address of a string variable that stores the value of the variable.
-func (v *VarSet) BindAddr(name, usage string) *string {
+func (v *VarSet) BindAddr(name, usage string, opts ...VarOption) *string {
 	p := new(string)
 	v.Var(checkedValue{
+		typ:   "bindaddr",
 		fn:    isBindAddr,
 		Value: newStringValue("", p),
-	}, name, usage)
+	}, name, usage, opts...)
 	return p
 }
 
 // DialAddr defines a string variable with specified name, usage string validated as a
 // dial address (host:port).
 // The return value is the address of a string variable that stores the value of the variable.
-func (v *VarSet) DialAddr(name, usage string) *string {
+func (v *VarSet) DialAddr(name, usage string, opts ...VarOption) *string {
 	p := new(string)
 	v.Var(checkedValue{
+		typ:   "dialaddr",
 		fn:    isDialAddr,
 		Value: newStringValue("", p),
-	}, name, usage)
+	}, name, usage, opts...)
 	return p
 }
 
 // URL defines a string variable with specified name, usage string validated as a URL.
 // The return value is the address of a URL variable that stores the value of the variable.
-func (v *VarSet) URL(name, usage string) *url.URL {
+func (v *VarSet) URL(name, usage string, opts ...VarOption) *url.URL {
 	p := new(url.URL)
-	v.Var(newURLValue(url.URL{}, p), name, usage)
+	v.Var(newURLValue(url.URL{}, p), name, usage, opts...)
 	return p
 }
 
 // Path defines a string variable with specified name, usage string validated as a local path.
 // The return value is the address of a string variable that stores the value of the variable.
-func (v *VarSet) Path(name, usage string) *string {
+func (v *VarSet) Path(name, usage string, opts ...VarOption) *string {
 	p := new(string)
 	v.Var(checkedValue{
+		typ:   "path",
 		fn:    isPath,
 		Value: newStringValue("", p),
-	}, name, usage)
+	}, name, usage, opts...)
 	return p
 }
 
-// Errors is returned from Parse.
+// Errors is returned from Parse.  Each error from Parse is a *VarError.
 type Errors []error
 
 // Error implements error.
@@ -386,37 +655,169 @@ type Getter interface {
 	Get(string) (string, bool)
 }
 
+// Lister is implemented by Getters which can list the names of all the
+// variables they contain.
+type Lister interface {
+	// Names returns the names of all variables.
+	Names() []string
+}
+
+// OSEnv is a Getter (and Lister) which reads from the process environment.
+var OSEnv Getter = osLookup{}
+
 type osLookup struct{}
 
 func (osLookup) Get(x string) (string, bool) { return os.LookupEnv(x) }
 
+func (osLookup) Names() []string {
+	var names []string
+	for _, kv := range os.Environ() {
+		if i := strings.Index(kv, "="); i > 0 {
+			names = append(names, kv[:i])
+		}
+	}
+	return names
+}
+
 // Parse parses variables from the environment provided by
-// the Getter.
+// the Getter.  Variables defined in child sets (see Sub) are included.
+//
+// In strict mode (see SetStrict), variables reported by Unclaimed are also
+// errors.
 func (v *VarSet) Parse(g Getter) error {
+	return v.parse(g, true)
+}
+
+// Check reports the errors Parse would return for the environment provided
+// by g, without changing the values of the variables or logging warnings.
+// It can be used to validate an environment before it is deployed.  Values
+// not defined in this package are only guaranteed to be unchanged if they
+// implement Validator.
+func (v *VarSet) Check(g Getter) error {
+	return v.parse(g, false)
+}
+
+// parse implements Parse, and if set is false, Check.
+func (v *VarSet) parse(g Getter, set bool) error {
 	var errs []error
 
+	setValue := func(x *Var, z string) error {
+		if !set {
+			return x.Validate(z)
+		}
+		return x.Value.Set(z)
+	}
+
 	for _, x := range v.vars {
-		z, ok := g.Get(x.Name)
+		z, a, ok := lookup(g, x)
+		if set {
+			x.source = ""
+		}
 		if !ok {
-			errs = append(errs, fmt.Errorf("missing env %v", x.Name))
+			if x.Default != "" {
+				if err := setValue(x, x.Default); err != nil {
+					errs = append(errs, setError(x, x.Default, true, err))
+				}
+				continue
+			}
+			if !x.Optional {
+				errs = append(errs, &VarError{Kind: MissingVar, Name: x.Name, Var: x})
+			}
 			continue
 		}
+		if set {
+			v.warnDeprecated(x, a)
+			x.source = x.Name
+			if a != nil {
+				x.source = a.Name
+			}
+		}
 
-		if err := x.Value.Set(z); err != nil {
-			errs = append(errs, fmt.Errorf("could not set env %v: %v", x.Name, err))
+		if err := setValue(x, z); err != nil {
+			errs = append(errs, setError(x, z, false, err))
 		}
 	}
 
+	if v.strict {
+		for _, u := range v.Unclaimed(g) {
+			errs = append(errs, &VarError{Kind: UnknownVar, Name: u.Name, Suggestion: u.Suggestion})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return Errors(errs)
+}
+
+// CheckExamples validates the example value of each variable (see Example)
+// without changing its value, and returns any errors.  It is intended to be
+// called from tests.
+func (v *VarSet) CheckExamples() error {
+	var errs []error
+	for _, x := range v.vars {
+		if x.Example == "" {
+			continue
+		}
+		if err := x.Validate(x.Example); err != nil {
+			errs = append(errs, fmt.Errorf("invalid example for env %v: %v", x.Name, err))
+		}
+	}
 	if len(errs) == 0 {
 		return nil
 	}
 	return Errors(errs)
 }
 
+// lookup retrieves the value of x from g, trying each of its aliases in
+// turn if x.Name is not set.  The returned alias is nil if the value was
+// read from x.Name.
+func lookup(g Getter, x *Var) (string, *Alias, bool) {
+	if z, ok := g.Get(x.Name); ok {
+		return z, nil, true
+	}
+	for i := range x.Aliases {
+		a := &x.Aliases[i]
+		if a.Name == x.Name {
+			continue
+		}
+		if z, ok := g.Get(a.Name); ok {
+			return z, a, true
+		}
+	}
+	return "", nil, false
+}
+
+// warnDeprecated logs a warning if x is deprecated, or its value was read
+// from a deprecated alias a.  The warning is logged by the logger of the set
+// x was defined in, which may be a child of v.
+func (v *VarSet) warnDeprecated(x *Var, a *Alias) {
+	l := v.logger()
+	if x.set != nil {
+		l = x.set.logger()
+	}
+	switch {
+	case x.Deprecated != "":
+		l.Printf("env %v is deprecated: %v", x.Name, x.Deprecated)
+	case a != nil && a.Deprecated != "":
+		l.Printf("env %v is deprecated, use %v instead: %v", a.Name, x.Name, a.Deprecated)
+	}
+}
+
 // CmdVar is the default variable set used for command-line based applications.
 // The name of the variable set (and hence all variable prefixes) is given
-// by CmdName.
-var CmdVar = NewVarSet(CmdName())
+// by CmdName, unless the CmdPrefixEnv environment variable is set.
+var CmdVar = newCmdVarSet()
+
+// CmdPrefixEnv is the environment variable which, if set, overrides
+// the prefix of CmdVar.
+const CmdPrefixEnv = "ENV_PREFIX"
+
+func newCmdVarSet() *VarSet {
+	v := NewVarSet(CmdName())
+	v.SetPrefixFromEnv(CmdPrefixEnv)
+	return v
+}
 
 // CmdName is used to create the default variable set name.
 var CmdName = func() string {
@@ -425,77 +826,83 @@ var CmdName = func() string {
 
 // String defines a string variable with specified name, usage string and validation checks.
 // The return value is the address of a string variable that stores the value of the variable.
-func String(name, usage string) *string {
-	return CmdVar.String(name, usage)
+func String(name, usage string, opts ...VarOption) *string {
+	return CmdVar.String(name, usage, opts...)
 }
 
 // StringRequired defines a required string variable with specified name and usage string..
 // The return value is the address of a string variable that stores the value of the variable.
-func StringRequired(name, usage string) *string {
-	return CmdVar.StringRequired(name, usage)
+func StringRequired(name, usage string, opts ...VarOption) *string {
+	return CmdVar.StringRequired(name, usage, opts...)
 }
 
 // BindAddr defines a string variable with specified name, usage string validated as a
 // bind address (host:port).
 // The return value is the address of a string variable that stores the value of the variable.
-func BindAddr(name, usage string) *string {
-	return CmdVar.BindAddr(name, usage)
+func BindAddr(name, usage string, opts ...VarOption) *string {
+	return CmdVar.BindAddr(name, usage, opts...)
 }
 
 // DialAddr defines a string variable with specified name, usage string validated as a
 // dial address (host:port).
 // The return value is the address of a string variable that stores the value of the variable.
-func DialAddr(name, usage string) *string {
-	return CmdVar.DialAddr(name, usage)
+func DialAddr(name, usage string, opts ...VarOption) *string {
+	return CmdVar.DialAddr(name, usage, opts...)
 }
 
 // URL defines a string variable with specified name, usage string validated as a URL.
 // The return value is the address of a URL variable that stores the value of the variable.
-func URL(name, usage string) *url.URL {
-	return CmdVar.URL(name, usage)
+func URL(name, usage string, opts ...VarOption) *url.URL {
+	return CmdVar.URL(name, usage, opts...)
 }
 
 // Path defines a string variable with specified name, usage string validated as a
 // local path.
 // The return value is the address of a string variable that stores the value of the variable.
-func Path(name, usage string) *string {
-	return CmdVar.Path(name, usage)
+func Path(name, usage string, opts ...VarOption) *string {
+	return CmdVar.Path(name, usage, opts...)
 }
 
 // Int defines an int variable with specified name and usage string.
 // The return value is the address of an int variable that stores the value of the variable.
-func Int(name string, usage string) *int {
-	return CmdVar.Int(name, usage)
+func Int(name, usage string, opts ...VarOption) *int {
+	return CmdVar.Int(name, usage, opts...)
 }
 
 // Int64 defines an int64 variable with specified name and usage string.
 // The return value is the address of an int variable that stores the value of the variable.
-func Int64(name string, usage string) *int64 {
-	return CmdVar.Int64(name, usage)
+func Int64(name, usage string, opts ...VarOption) *int64 {
+	return CmdVar.Int64(name, usage, opts...)
 }
 
 // Float32 defines an float32 variable with specified name and usage string.
 // The return value is the address of an int variable that stores the value of the variable.
-func Float32(name string, usage string) *float32 {
-	return CmdVar.Float32(name, usage)
+func Float32(name, usage string, opts ...VarOption) *float32 {
+	return CmdVar.Float32(name, usage, opts...)
 }
 
 // Float64 defines an float64 variable with specified name and usage string.
 // The return value is the address of an int variable that stores the value of the variable.
-func Float64(name string, usage string) *float64 {
-	return CmdVar.Float64(name, usage)
+func Float64(name, usage string, opts ...VarOption) *float64 {
+	return CmdVar.Float64(name, usage, opts...)
 }
 
 // Bool defines a bool variable with specified name and usage string.
 // The return value is the address of a bool variable that stores the value of the variable.
-func Bool(name string, usage string) *bool {
-	return CmdVar.Bool(name, usage)
+func Bool(name, usage string, opts ...VarOption) *bool {
+	return CmdVar.Bool(name, usage, opts...)
 }
 
 // Duration defines a time.Duration variable with specified name, usage string and validation checks.
 // The return value is the address of a time.Duration variable that stores the value of the variable.
-func Duration(name string, usage string) *time.Duration {
-	return CmdVar.Duration(name, usage)
+func Duration(name, usage string, opts ...VarOption) *time.Duration {
+	return CmdVar.Duration(name, usage, opts...)
+}
+
+// Sub returns a child variable set of CmdVar with the given name.
+// See VarSet.Sub.
+func Sub(name string) *VarSet {
+	return CmdVar.Sub(name)
 }
 
 // Visit visits the variables in the order in which they were defined, calling fn for each.
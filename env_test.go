@@ -1,6 +1,9 @@
 package env_test
 
 import (
+	"fmt"
+	"os"
+	"reflect"
 	"testing"
 
 	"code.sajari.com/env"
@@ -54,3 +57,211 @@ func TestAll(t *testing.T) {
 		}
 	})
 }
+
+func TestSub(t *testing.T) {
+	vs := env.NewVarSet("my-svc")
+	listen := vs.BindAddr("LISTEN", "listen test")
+	db := vs.Sub("db")
+	host := db.String("HOST", "host test")
+	replica := db.Sub("REPLICA").String("HOST", "replica host test")
+	workers := vs.Int("WORKERS", "workers test")
+
+	if got := vs.Sub("db"); got != db {
+		t.Errorf("vs.Sub(%q) returned a new set, expected existing child", "db")
+	}
+	if got := db.Parent(); got != vs {
+		t.Errorf("db.Parent() = %p, expected %p", got, vs)
+	}
+	if got, want := db.Prefix(), "MY_SVC_DB"; got != want {
+		t.Errorf("db.Prefix() = %q, expected %q", got, want)
+	}
+
+	tg := testGetter{
+		"MY_SVC_LISTEN":          ":1234",
+		"MY_SVC_DB_HOST":         "db",
+		"MY_SVC_DB_REPLICA_HOST": "replica",
+		"MY_SVC_WORKERS":         "4",
+	}
+	if err := vs.Parse(tg); err != nil {
+		t.Fatalf("unexpected error from Parse: %v", err)
+	}
+	if *listen != ":1234" || *host != "db" || *replica != "replica" || *workers != 4 {
+		t.Errorf("Parse set (%q, %q, %q, %d)", *listen, *host, *replica, *workers)
+	}
+
+	var names []string
+	vs.Visit(func(v *env.Var) { names = append(names, v.Name) })
+	want := []string{"MY_SVC_LISTEN", "MY_SVC_DB_HOST", "MY_SVC_DB_REPLICA_HOST", "MY_SVC_WORKERS"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("vs.Visit() visited %v, expected %v", names, want)
+	}
+
+	names = nil
+	db.Visit(func(v *env.Var) { names = append(names, v.Name) })
+	want = []string{"MY_SVC_DB_HOST", "MY_SVC_DB_REPLICA_HOST"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("db.Visit() visited %v, expected %v", names, want)
+	}
+}
+
+func TestSetPrefix(t *testing.T) {
+	vs := env.NewVarSet("server.test")
+	port := vs.Int("PORT", "port test")
+	db := vs.Sub("DB")
+	url := db.String("URL", "url test")
+
+	vs.SetPrefix("MYSVC")
+	want := []string{"MYSVC_PORT", "MYSVC_DB_URL"}
+	if got := names(vs); !reflect.DeepEqual(got, want) {
+		t.Errorf("after SetPrefix, names = %v, expected %v", got, want)
+	}
+
+	vs.SetSeparator("__")
+	want = []string{"MYSVC__PORT", "MYSVC__DB__URL"}
+	if got := names(vs); !reflect.DeepEqual(got, want) {
+		t.Errorf("after SetSeparator, names = %v, expected %v", got, want)
+	}
+
+	vs.SetPrefix("")
+	want = []string{"PORT", "DB__URL"}
+	if got := names(vs); !reflect.DeepEqual(got, want) {
+		t.Errorf("after SetPrefix(\"\"), names = %v, expected %v", got, want)
+	}
+
+	if err := vs.Parse(testGetter{"PORT": "80", "DB__URL": "db"}); err != nil {
+		t.Fatalf("unexpected error from Parse: %v", err)
+	}
+	if *port != 80 || *url != "db" {
+		t.Errorf("Parse set (%d, %q), expected (80, \"db\")", *port, *url)
+	}
+}
+
+func TestSetPrefixFromEnv(t *testing.T) {
+	const key = "TEST_ENV_PREFIX"
+	vs := env.NewVarSet("app")
+	vs.String("NAME", "name test")
+
+	os.Unsetenv(key)
+	vs.SetPrefixFromEnv(key)
+	if got, want := names(vs), []string{"APP_NAME"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with %v unset, names = %v, expected %v", key, got, want)
+	}
+
+	os.Setenv(key, "MYSVC")
+	defer os.Unsetenv(key)
+	vs.SetPrefixFromEnv(key)
+	if got, want := names(vs), []string{"MYSVC_NAME"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with %v set, names = %v, expected %v", key, got, want)
+	}
+}
+
+func TestAliasUnprefixed(t *testing.T) {
+	vs := env.NewVarSet("mysvc")
+	port := vs.Int("PORT", "port test")
+	vs.AliasUnprefixed("PORT", "DATABASE_URL")
+	dbURL := vs.String("DATABASE_URL", "database url test")
+
+	if err := vs.Parse(testGetter{"PORT": "8080", "DATABASE_URL": "postgres://db"}); err != nil {
+		t.Fatalf("unexpected error from Parse: %v", err)
+	}
+	if *port != 8080 || *dbURL != "postgres://db" {
+		t.Errorf("Parse set (%d, %q), expected (8080, \"postgres://db\")", *port, *dbURL)
+	}
+
+	// Prefixed names take precedence.
+	if err := vs.Parse(testGetter{"PORT": "8080", "MYSVC_PORT": "9090", "DATABASE_URL": ""}); err != nil {
+		t.Fatalf("unexpected error from Parse: %v", err)
+	}
+	if *port != 9090 {
+		t.Errorf("Parse set %d, expected 9090", *port)
+	}
+}
+
+func names(vs *env.VarSet) []string {
+	var out []string
+	vs.Visit(func(v *env.Var) { out = append(out, v.Name) })
+	return out
+}
+
+type testLogger []string
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	*l = append(*l, fmt.Sprintf(format, v...))
+}
+
+func TestAliases(t *testing.T) {
+	tests := []struct {
+		name   string
+		tg     testGetter
+		out    int
+		source string
+		logs   []string
+	}{
+		{"canonical", testGetter{"APP_PORT": "1", "PORT": "2", "APP_OLD_PORT": "3"}, 1, "APP_PORT", nil},
+		{"alias", testGetter{"PORT": "2", "APP_OLD_PORT": "3"}, 2, "PORT", nil},
+		{"deprecated", testGetter{"APP_OLD_PORT": "3"}, 3, "APP_OLD_PORT", []string{
+			"env APP_OLD_PORT is deprecated, use APP_PORT instead: renamed in v2",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs testLogger
+			vs := env.NewVarSet("app")
+			vs.SetLogger(&logs)
+			port := vs.Int("PORT", "port test", env.Aliases("PORT"), env.DeprecatedAlias("APP_OLD_PORT", "renamed in v2"))
+
+			if err := vs.Parse(tt.tg); err != nil {
+				t.Fatalf("unexpected error from Parse: %v", err)
+			}
+			if *port != tt.out {
+				t.Errorf("Parse set %d, expected %d", *port, tt.out)
+			}
+			vs.Visit(func(v *env.Var) {
+				if got := v.Source(); got != tt.source {
+					t.Errorf("Source() = %q, expected %q", got, tt.source)
+				}
+			})
+			if !reflect.DeepEqual([]string(logs), tt.logs) {
+				t.Errorf("Parse logged %q, expected %q", logs, tt.logs)
+			}
+		})
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	var logs testLogger
+	vs := env.NewVarSet("app")
+	vs.SetLogger(&logs)
+	vs.Sub("sub").Bool("DEBUG", "debug test", env.Deprecated("use APP_LOG_LEVEL"))
+
+	if err := vs.Parse(testGetter{"APP_SUB_DEBUG": "true"}); err != nil {
+		t.Fatalf("unexpected error from Parse: %v", err)
+	}
+	want := []string{"env APP_SUB_DEBUG is deprecated: use APP_LOG_LEVEL"}
+	if !reflect.DeepEqual([]string(logs), want) {
+		t.Errorf("Parse logged %q, expected %q", logs, want)
+	}
+}
+
+func TestDeprecatedSubLogger(t *testing.T) {
+	var logs, subLogs testLogger
+	vs := env.NewVarSet("app")
+	vs.SetLogger(&logs)
+	sub := vs.Sub("sub")
+	sub.SetLogger(&subLogs)
+	sub.Bool("DEBUG", "debug test", env.Deprecated("use APP_LOG_LEVEL"))
+	vs.Int("PORT", "port test", env.DeprecatedAlias("APP_OLD_PORT", "renamed in v2"))
+
+	if err := vs.Parse(testGetter{"APP_SUB_DEBUG": "true", "APP_OLD_PORT": "1"}); err != nil {
+		t.Fatalf("unexpected error from Parse: %v", err)
+	}
+	want := []string{"env APP_SUB_DEBUG is deprecated: use APP_LOG_LEVEL"}
+	if !reflect.DeepEqual([]string(subLogs), want) {
+		t.Errorf("Parse logged %q to the logger of the sub set, expected %q", subLogs, want)
+	}
+	want = []string{"env APP_OLD_PORT is deprecated, use APP_PORT instead: renamed in v2"}
+	if !reflect.DeepEqual([]string(logs), want) {
+		t.Errorf("Parse logged %q to the logger of the root set, expected %q", logs, want)
+	}
+}
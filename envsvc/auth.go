@@ -0,0 +1,104 @@
+package envsvc
+
+import (
+	"crypto/subtle"
+	"crypto/x509"
+	"net/http"
+	"strings"
+
+	"code.sajari.com/env"
+)
+
+// HandlerOption configures the handler returned by Handler.
+type HandlerOption func(*handlerConfig)
+
+type handlerConfig struct {
+	vs         *env.VarSet
+	middleware []func(http.Handler) http.Handler
+	overrides  *Overrides
+}
+
+// VarSet returns a HandlerOption which serves the variables of vs instead of
+// env.CmdVar.
+func VarSet(vs *env.VarSet) HandlerOption {
+	return func(c *handlerConfig) {
+		c.vs = vs
+	}
+}
+
+// Middleware returns a HandlerOption which wraps the handler with mw.
+// Middleware is applied in the order given, so the first option is the
+// outermost and sees each request first.
+func Middleware(mw func(http.Handler) http.Handler) HandlerOption {
+	return func(c *handlerConfig) {
+		c.middleware = append(c.middleware, mw)
+	}
+}
+
+// BearerToken returns a HandlerOption which requires requests to have an
+// "Authorization: Bearer <token>" header matching *token.  The token is read
+// on each request, so it can be a secret variable which is set by Parse:
+//
+//	token := env.String("DEBUG_TOKEN", "token for /debug/env", env.Secret())
+//	envsvc.Register(nil, envsvc.BearerToken(token))
+//
+// All requests are rejected while *token is empty.
+func BearerToken(token *string) HandlerOption {
+	return Middleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !bearerTokenMatch(r.Header.Get("Authorization"), *token) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="env"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+}
+
+// bearerTokenMatch reports whether the Authorization header value is a
+// bearer token equal to token, which must not be empty.
+func bearerTokenMatch(header, token string) bool {
+	const scheme = "bearer "
+	if token == "" || len(header) < len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) {
+		return false
+	}
+	got := strings.TrimSpace(header[len(scheme):])
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// ClientCert returns a HandlerOption which requires requests to be made over
+// TLS with a client certificate verified by the server (see
+// tls.Config.ClientAuth), and, if check is non-nil, that check returns nil
+// for the certificate, for example to restrict the allowed subjects.
+func ClientCert(check func(*x509.Certificate) error) HandlerOption {
+	return Middleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+				http.Error(w, "client certificate required", http.StatusForbidden)
+				return
+			}
+			if check != nil {
+				if err := check(r.TLS.VerifiedChains[0][0]); err != nil {
+					http.Error(w, "forbidden", http.StatusForbidden)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+}
+
+// Authorize returns a HandlerOption which rejects requests for which fn
+// returns an error.  The error is not included in the response.
+func Authorize(fn func(*http.Request) error) HandlerOption {
+	return Middleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := fn(r); err != nil {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+}
@@ -0,0 +1,104 @@
+package envsvc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	r := httptest.NewRequest("GET", "/debug/env", nil)
+	if _, pattern := http.DefaultServeMux.Handler(r); pattern != "" {
+		t.Errorf("/debug/env is registered on http.DefaultServeMux at %q, expected opt-in", pattern)
+	}
+
+	vs := newTestVarSet(t)
+	mux := http.NewServeMux()
+	Register(mux, VarSet(vs))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/debug/env?short", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /debug/env = %d, expected %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestHandlerAuth(t *testing.T) {
+	vs := newTestVarSet(t)
+	token := ""
+
+	admin := &x509.Certificate{Subject: pkix.Name{CommonName: "admin"}}
+	other := &x509.Certificate{Subject: pkix.Name{CommonName: "other"}}
+	verified := func(c *x509.Certificate) *tls.ConnectionState {
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{c}}}
+	}
+	checkAdmin := func(c *x509.Certificate) error {
+		if c.Subject.CommonName != "admin" {
+			return errors.New("not admin")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name   string
+		opts   []HandlerOption
+		token  string
+		header string
+		tls    *tls.ConnectionState
+		code   int
+	}{
+		{"no auth", nil, "", "", nil, http.StatusOK},
+		{"bearer", []HandlerOption{BearerToken(&token)}, "s3cret", "Bearer s3cret", nil, http.StatusOK},
+		{"bearer scheme case", []HandlerOption{BearerToken(&token)}, "s3cret", "bearer s3cret", nil, http.StatusOK},
+		{"bearer missing", []HandlerOption{BearerToken(&token)}, "s3cret", "", nil, http.StatusUnauthorized},
+		{"bearer wrong", []HandlerOption{BearerToken(&token)}, "s3cret", "Bearer s3cre", nil, http.StatusUnauthorized},
+		{"bearer basic", []HandlerOption{BearerToken(&token)}, "s3cret", "Basic s3cret", nil, http.StatusUnauthorized},
+		{"bearer unset", []HandlerOption{BearerToken(&token)}, "", "Bearer ", nil, http.StatusUnauthorized},
+		{"cert", []HandlerOption{ClientCert(checkAdmin)}, "", "", verified(admin), http.StatusOK},
+		{"cert any", []HandlerOption{ClientCert(nil)}, "", "", verified(other), http.StatusOK},
+		{"cert rejected", []HandlerOption{ClientCert(checkAdmin)}, "", "", verified(other), http.StatusForbidden},
+		{"cert unverified", []HandlerOption{ClientCert(nil)}, "", "", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{admin}}, http.StatusForbidden},
+		{"cert no tls", []HandlerOption{ClientCert(nil)}, "", "", nil, http.StatusForbidden},
+		{"authorize", []HandlerOption{Authorize(func(*http.Request) error { return nil })}, "", "", nil, http.StatusOK},
+		{"authorize rejected", []HandlerOption{Authorize(func(*http.Request) error { return errors.New("no") })}, "", "", nil, http.StatusForbidden},
+		{"all", []HandlerOption{BearerToken(&token), ClientCert(checkAdmin)}, "s3cret", "Bearer s3cret", verified(admin), http.StatusOK},
+		{"all rejected", []HandlerOption{BearerToken(&token), ClientCert(checkAdmin)}, "s3cret", "Bearer s3cret", verified(other), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token = tt.token
+			r := httptest.NewRequest("GET", "/debug/env", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			r.TLS = tt.tls
+			w := httptest.NewRecorder()
+			Handler(append(tt.opts, VarSet(vs))...).ServeHTTP(w, r)
+			if w.Code != tt.code {
+				t.Errorf("status = %d, expected %d", w.Code, tt.code)
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("WWW-Authenticate not set")
+			}
+		})
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	mw := func(name string) HandlerOption {
+		return Middleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		})
+	}
+	Handler(mw("first"), mw("second"), VarSet(newTestVarSet(t))).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/debug/env", nil))
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "second" {
+		t.Errorf("middleware called in order %q, expected [first second]", calls)
+	}
+}
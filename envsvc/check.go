@@ -0,0 +1,253 @@
+package envsvc
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	"code.sajari.com/env"
+)
+
+// checkReport is the result of -env-check.
+type checkReport struct {
+	vs          *env.VarSet
+	ok          bool
+	problems    []checkProblem
+	fingerprint string // of the values parsed, if ok
+}
+
+// checkProblem is a problem found by -env-check.
+type checkProblem struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`            // see env.ErrorKind
+	Value    string `json:"value,omitempty"` // redacted if secret
+	Message  string `json:"message"`
+	Severity string `json:"severity"` // "error", or "warning" for unclaimed variables
+}
+
+// newCheckReport returns the report of the errors from parsing vs, and the
+// unclaimed variables which aren't errors.
+func newCheckReport(vs *env.VarSet, errs env.Errors, unclaimed []env.UnclaimedVar) checkReport {
+	r := checkReport{vs: vs, ok: len(errs) == 0, problems: []checkProblem{}}
+	for _, err := range errs {
+		p := checkProblem{Kind: "error", Message: err.Error(), Severity: "error"}
+		var ve *env.VarError
+		if errors.As(err, &ve) {
+			p.Name, p.Kind, p.Value = ve.Name, ve.Kind.String(), ve.Value
+			if ve.Var != nil && ve.Var.Secret && p.Value != "" {
+				p.Message = secretMessage(ve)
+				p.Value = redacted
+			}
+		}
+		r.problems = append(r.problems, p)
+	}
+	for _, u := range unclaimed {
+		r.problems = append(r.problems, checkProblem{
+			Name:     u.Name,
+			Kind:     env.UnknownVar.String(),
+			Message:  u.String(),
+			Severity: "warning",
+		})
+	}
+	return r
+}
+
+// secretMessage returns the message for ve, an error setting a secret
+// variable.  It is built from the fields of ve rather than ve.Err, as errors
+// from Set may include the value in any form.
+func secretMessage(ve *env.VarError) string {
+	problem := "invalid value"
+	if ve.Kind == env.ConstraintViolation {
+		problem = "value does not satisfy its constraints"
+		if c := ve.Var.Constraints.String(); c != "" {
+			problem = "value does not satisfy constraints: " + c
+		}
+	}
+	if ve.Default {
+		return fmt.Sprintf("could not set env %v to default: %v", ve.Name, problem)
+	}
+	return fmt.Sprintf("could not set env %v: %v", ve.Name, problem)
+}
+
+// checkFormats are the values of -env-check-format other than text, and the
+// functions which write reports in them.
+var checkFormats = map[string]func(io.Writer, checkReport) error{
+	"json":  writeCheckJSON,
+	"sarif": writeCheckSARIF,
+	"junit": writeCheckJUnit,
+}
+
+func writeCheckJSON(w io.Writer, r checkReport) error {
+	return writeJSON(w, struct {
+		OK          bool           `json:"ok"`
+		Fingerprint string         `json:"fingerprint,omitempty"`
+		Problems    []checkProblem `json:"problems"`
+	}{r.ok, r.fingerprint, r.problems})
+}
+
+// sarifVersion is the version of SARIF written by writeCheckSARIF.
+const sarifVersion = "2.1.0"
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// sarifRules describe each kind of problem.
+var sarifRules = []sarifRule{
+	{env.MissingVar.String(), sarifMessage{"Required environment variable is not set."}},
+	{env.InvalidValue.String(), sarifMessage{"Environment variable has an invalid value."}},
+	{env.ConstraintViolation.String(), sarifMessage{"Environment variable value does not satisfy its constraints."}},
+	{env.UnknownVar.String(), sarifMessage{"Environment variable is not used."}},
+}
+
+func writeCheckSARIF(w io.Writer, r checkReport) error {
+	results := []sarifResult{}
+	for _, p := range r.problems {
+		res := sarifResult{
+			RuleID:  p.Kind,
+			Level:   p.Severity,
+			Message: sarifMessage{p.Message},
+		}
+		if p.Name != "" {
+			res.Locations = []sarifLocation{{
+				LogicalLocations: []sarifLogicalLocation{{Name: p.Name, Kind: "variable"}},
+			}}
+		}
+		if p.Value != "" {
+			res.Properties = map[string]string{"value": p.Value}
+		}
+		results = append(results, res)
+	}
+
+	return writeJSON(w, sarifLog{
+		Version: sarifVersion,
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "env",
+				InformationURI: "https://github.com/sajari/env",
+				Rules:          sarifRules,
+			}},
+			Results: results,
+		}},
+	})
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+	SystemErr string          `xml:"system-err,omitempty"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+	Value   string `xml:",chardata"`
+}
+
+// writeCheckJUnit writes a JUnit XML report with a test case for each
+// variable, which fails if there is an error for it.  Warnings are written
+// to the system-err of the test suite.
+func writeCheckJUnit(w io.Writer, r checkReport) error {
+	suite := junitTestSuite{Name: r.vs.Name()}
+	if suite.Name == "" {
+		suite.Name = "env"
+	}
+
+	failures := make(map[string]*junitFailure)
+	var other []junitTestCase // failures for names which aren't variables
+	for _, p := range r.problems {
+		if p.Severity == "warning" {
+			suite.SystemErr += p.Message + "\n"
+			continue
+		}
+		f := &junitFailure{Type: p.Kind, Message: p.Message}
+		if p.Value != "" {
+			f.Value = fmt.Sprintf("value: %q", p.Value)
+		}
+		if p.Kind == env.UnknownVar.String() || p.Name == "" {
+			other = append(other, junitTestCase{Name: p.Name, ClassName: suite.Name, Failure: f})
+			continue
+		}
+		failures[p.Name] = f
+	}
+
+	r.vs.Visit(func(v *env.Var) {
+		suite.TestCases = append(suite.TestCases, junitTestCase{Name: v.Name, ClassName: suite.Name, Failure: failures[v.Name]})
+	})
+	suite.TestCases = append(suite.TestCases, other...)
+	for _, tc := range suite.TestCases {
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+	}
+	suite.Tests = len(suite.TestCases)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,152 @@
+package envsvc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"code.sajari.com/env"
+)
+
+// durationPattern matches the values accepted by time.ParseDuration.
+const durationPattern = `^[-+]?(0|(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+)$`
+
+func dumpCUE(w io.Writer, vs *env.VarSet, _ env.Getter) error {
+	fmt.Fprintf(w, "package %s\n\n", cuePackage(vs.Name()))
+	fmt.Fprintf(w, "#Env: [string]: string | number | bool")
+	vs.Visit(func(v *env.Var) {
+		// Insert newlines between fields to avoid cue fmt issues
+		fmt.Fprintf(w, "\n\n")
+		if v.Usage != "" {
+			fmt.Fprintf(w, "// %v\n", cueComment(v.Usage))
+		}
+		if v.Deprecated != "" {
+			fmt.Fprintf(w, "// Deprecated: %v\n", cueComment(v.Deprecated))
+		}
+		optional := ""
+		if !v.Required() {
+			optional = "?"
+		}
+		fmt.Fprintf(w, "#Env: %v%v: %v", cueString(v.Name), optional, cueType(v))
+	})
+	_, err := fmt.Fprintln(w, "")
+	return err
+}
+
+// cueType returns the CUE type of v, including its constraints and
+// default.  Patterns are only included for variables with string types, and
+// minimums and maximums for numeric types, as CUE can't apply them to other
+// types (env checks them against the text of the value).
+func cueType(v *env.Var) string {
+	var typ string
+	str, num := false, false
+	switch v.Type() {
+	case "int", "int64":
+		typ, num = "int", true
+	case "float32", "float64":
+		typ, num = "float", true
+	case "bool":
+		typ = "bool"
+	case "duration":
+		typ, str = "string & =~"+cueString(durationPattern), true
+	default:
+		typ, str = "string", true
+	}
+
+	c := v.Constraints
+	hasPattern := str && c.Pattern != ""
+	hasMin := num && c.Min != nil
+	hasMax := num && c.Max != nil
+	if len(c.Enum) > 0 {
+		lits := make([]string, len(c.Enum))
+		for i, x := range c.Enum {
+			lits[i] = cueLiteral(v, x)
+		}
+		typ = strings.Join(lits, " | ")
+		if len(lits) > 1 && (hasPattern || hasMin || hasMax) {
+			typ = "(" + typ + ")"
+		}
+	}
+	if hasPattern {
+		typ += " & =~" + cueString(c.Pattern)
+	}
+	if hasMin {
+		typ += " & >=" + strconv.FormatFloat(*c.Min, 'f', -1, 64)
+	}
+	if hasMax {
+		typ += " & <=" + strconv.FormatFloat(*c.Max, 'f', -1, 64)
+	}
+
+	if v.Default != "" {
+		typ = "*" + cueLiteral(v, v.Default) + " | " + typ
+	}
+	return typ
+}
+
+// cueLiteral returns x as a CUE literal of the type of v, falling back to a
+// string if x isn't a valid value of that type.
+func cueLiteral(v *env.Var, x string) string {
+	switch y := typedValue(v, x).(type) {
+	case int64:
+		return strconv.FormatInt(y, 10)
+	case float64:
+		s := strconv.FormatFloat(y, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s
+	case bool:
+		return strconv.FormatBool(y)
+	}
+	return cueString(x)
+}
+
+// typedValue converts x to an int64, float64 or bool according to the type
+// of v, falling back to x if it isn't a valid value of that type.
+func typedValue(v *env.Var, x string) interface{} {
+	switch v.Type() {
+	case "int", "int64":
+		if n, err := strconv.ParseInt(x, 10, 64); err == nil {
+			return n
+		}
+	case "float32", "float64":
+		if f, err := strconv.ParseFloat(x, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return f
+		}
+	case "bool":
+		if b, err := strconv.ParseBool(x); err == nil {
+			return b
+		}
+	}
+	return x
+}
+
+// cueString returns x as a CUE string literal.  JSON strings are valid CUE
+// strings, unlike Go's quoting (%q) which can produce \x escapes.
+func cueString(x string) string {
+	b, _ := json.Marshal(x)
+	return string(b)
+}
+
+// cueComment replaces newlines in x so that it fits in a line comment.
+func cueComment(x string) string {
+	return strings.Replace(x, "\n", " ", -1)
+}
+
+// cuePackage converts name to a valid CUE package name.
+func cuePackage(name string) string {
+	s := strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "env" + s
+	}
+	return s
+}
@@ -0,0 +1,33 @@
+package envsvc
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"code.sajari.com/env"
+)
+
+// writeDiff writes one line for each change to w, of the form
+//
+//	changed NAME: "before" -> "after"
+//
+// where unset values are written as (unset), and the values of secret
+// variables are redacted.
+func writeDiff(w io.Writer, changes []env.Change) {
+	for _, c := range changes {
+		secret := c.Var != nil && c.Var.Secret
+		fmt.Fprintf(w, "%-7v %v: %v -> %v\n", c.Kind, c.Name,
+			diffValue(c.Old, c.HasOld, secret), diffValue(c.New, c.HasNew, secret))
+	}
+}
+
+func diffValue(x string, ok, secret bool) string {
+	switch {
+	case !ok:
+		return "(unset)"
+	case secret:
+		return redacted
+	}
+	return strconv.Quote(x)
+}
@@ -0,0 +1,205 @@
+package envsvc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"code.sajari.com/env"
+)
+
+// dumpFunc writes the variables in vs, with values from g, to w.
+type dumpFunc func(w io.Writer, vs *env.VarSet, g env.Getter) error
+
+func dumpJSON(w io.Writer, vs *env.VarSet, g env.Getter) error {
+	var m jsonObject
+	vs.Visit(func(v *env.Var) {
+		m = append(m, field{v.Name, get(v, g)})
+	})
+	return writeJSON(w, m)
+}
+
+type yamlVar struct {
+	Name  yamlString `yaml:"name"`
+	Value yamlString `yaml:"value"`
+}
+
+func dumpYAML(w io.Writer, vs *env.VarSet, g env.Getter) error {
+	vars := []yamlVar{}
+	vs.Visit(func(v *env.Var) {
+		vars = append(vars, yamlVar{yamlString(v.Name), yamlString(get(v, g))})
+	})
+	return writeYAML(w, vars)
+}
+
+// dumpEnv writes an export statement for each variable.  Variables which
+// are not set use their example value, or otherwise their default, so that
+// the output is a runnable example config.
+func dumpEnv(w io.Writer, vs *env.VarSet, g env.Getter) error {
+	first := true
+	vs.Visit(func(v *env.Var) {
+		if !first {
+			fmt.Fprintf(w, "\n")
+		}
+		first = false
+
+		z, ok := v.Get(g)
+		switch {
+		case ok:
+		case v.Example != "":
+			z = v.Example
+		default:
+			z = v.Default
+		}
+		writeEnvVar(w, v, z)
+	})
+	return nil
+}
+
+// writeEnvVar writes an export statement for v with the given value,
+// preceded by its usage as a comment.
+func writeEnvVar(w io.Writer, v *env.Var, value string) {
+	fmt.Fprintf(w, "# %v\nexport %v=%q\n", v.Usage, v.Name, value)
+}
+
+// get returns the value of v from g, which may have been set using one of
+// its aliases.
+func get(v *env.Var, g env.Getter) string {
+	z, _ := v.Get(g)
+	return z
+}
+
+// jsonObject is a JSON object of string values which, unlike a map,
+// preserves the order of its fields.
+type jsonObject []field
+
+// field is a named string value.
+type field struct {
+	Name, Value string
+}
+
+func (m jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i, f := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(f.Name); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := enc.Encode(f.Value); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// writeJSON writes x to w as indented JSON.
+func writeJSON(w io.Writer, x interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	return enc.Encode(x)
+}
+
+// yamlString is a string which is always encoded so that it decodes to
+// the same bytes: strings with control or non-printable characters are
+// double-quoted (the YAML encoder's block styles don't preserve all of
+// them), and invalid UTF-8 is encoded as !!binary.
+type yamlString string
+
+func (s yamlString) MarshalYAML() (interface{}, error) {
+	x := string(s)
+	if !utf8.ValidString(x) {
+		return &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   "!!binary",
+			Value: base64.StdEncoding.EncodeToString([]byte(x)),
+		}, nil
+	}
+	for _, r := range x {
+		if !unicode.IsPrint(r) {
+			return &yaml.Node{
+				Kind:  yaml.ScalarNode,
+				Tag:   "!!str",
+				Style: yaml.DoubleQuotedStyle,
+				Value: x,
+			}, nil
+		}
+	}
+	return x, nil
+}
+
+// yamlMap is a YAML mapping of strings which, unlike a map, preserves the
+// order of its fields.
+type yamlMap []field
+
+func (m yamlMap) MarshalYAML() (interface{}, error) {
+	n := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range m {
+		var k, v yaml.Node
+		if err := k.Encode(yamlString(f.Name)); err != nil {
+			return nil, err
+		}
+		if err := v.Encode(yamlString(f.Value)); err != nil {
+			return nil, err
+		}
+		n.Content = append(n.Content, &k, &v)
+	}
+	return n, nil
+}
+
+// writeYAML writes each of docs to w as a separate YAML document.
+func writeYAML(w io.Writer, docs ...interface{}) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	for _, d := range docs {
+		if err := enc.Encode(d); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory as
+// name, and then renames it to name so that readers never see a partially
+// written file.  The file is created with mode 0600 as it may contain
+// secrets.
+func writeFileAtomic(name string, data []byte) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
@@ -0,0 +1,117 @@
+package envsvc
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"code.sajari.com/env"
+)
+
+type mapGetter map[string]string
+
+func (g mapGetter) Get(x string) (string, bool) {
+	v, ok := g[x]
+	return v, ok
+}
+
+// jsonString returns x as it is expected to be decoded from JSON: invalid
+// UTF-8 bytes are replaced by U+FFFD, which converting to []rune also does.
+func jsonString(x string) string {
+	return string([]rune(x))
+}
+
+func FuzzEncoders(f *testing.F) {
+	for _, x := range []string{
+		"", "plain", "\x00", "\a\b\f\v", "\xff\xfe", "line\nbreak", "key: value", "- item",
+		"# comment", "'single'", "\"double\"", "<&>", "  ", "true", "1.5", "~", "null",
+	} {
+		f.Add("NAME", x)
+		f.Add(x, "value")
+	}
+
+	f.Fuzz(func(t *testing.T, name, value string) {
+		if name == "OTHER" {
+			t.Skip()
+		}
+		vs := env.NewVarSet("")
+		vs.String(name, "usage of "+name)
+		vs.String("OTHER", "other")
+		g := mapGetter{name: value, "OTHER": "other"}
+		if err := vs.Parse(g); err != nil {
+			t.Fatalf("vs.Parse() = %v", err)
+		}
+
+		wantJSON := map[string]string{jsonString(name): jsonString(value), "OTHER": "other"}
+
+		var buf bytes.Buffer
+		if err := dumpJSON(&buf, vs, g); err != nil {
+			t.Fatalf("dumpJSON() = %v", err)
+		}
+		var gotJSON map[string]string
+		if err := json.Unmarshal(buf.Bytes(), &gotJSON); err != nil {
+			t.Fatalf("dumpJSON() wrote invalid JSON %q: %v", buf.Bytes(), err)
+		}
+		checkMap(t, "dumpJSON", gotJSON, wantJSON)
+
+		var all []*env.Var
+		vs.Visit(func(v *env.Var) {
+			all = append(all, v)
+		})
+
+		buf.Reset()
+		if err := shortHandler(&buf, all); err != nil {
+			t.Fatalf("shortHandler() = %v", err)
+		}
+		gotJSON = nil
+		if err := json.Unmarshal(buf.Bytes(), &gotJSON); err != nil {
+			t.Fatalf("shortHandler() wrote invalid JSON %q: %v", buf.Bytes(), err)
+		}
+		checkMap(t, "shortHandler", gotJSON, wantJSON)
+
+		buf.Reset()
+		if err := detailHandler(&buf, all, vs.Fingerprint()); err != nil {
+			t.Fatalf("detailHandler() = %v", err)
+		}
+		var detail detailEnv
+		if err := json.Unmarshal(buf.Bytes(), &detail); err != nil {
+			t.Fatalf("detailHandler() wrote invalid JSON %q: %v", buf.Bytes(), err)
+		}
+		gotJSON = make(map[string]string)
+		for _, v := range detail.Env {
+			gotJSON[string(v.Name)] = string(v.Value)
+		}
+		checkMap(t, "detailHandler", gotJSON, wantJSON)
+
+		buf.Reset()
+		if err := dumpYAML(&buf, vs, g); err != nil {
+			t.Fatalf("dumpYAML() = %v", err)
+		}
+		var vars []struct {
+			Name  string `yaml:"name"`
+			Value string `yaml:"value"`
+		}
+		if err := yaml.Unmarshal(buf.Bytes(), &vars); err != nil {
+			t.Fatalf("dumpYAML() wrote invalid YAML %q: %v", buf.Bytes(), err)
+		}
+		gotYAML := make(map[string]string)
+		for _, v := range vars {
+			gotYAML[v.Name] = v.Value
+		}
+		checkMap(t, "dumpYAML", gotYAML, map[string]string{name: value, "OTHER": "other"})
+	})
+}
+
+func checkMap(t *testing.T, fn string, got, want map[string]string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%v() wrote %d variables %q, expected %d", fn, len(got), got, len(want))
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%v() wrote %q = %q, expected %q", fn, k, got[k], v)
+		}
+	}
+}
@@ -1,98 +1,346 @@
 // Package envsvc provides convenience methods for using env with
 // services.
 //
-// It exposes these variables via HTTP at /debug/env in JSON format.
+// Register exposes these variables via HTTP at /debug/env in JSON, YAML or
+// dotenv format (see Handler).
 package envsvc
 
 import (
+	"bufio"
+	"bytes"
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 
 	"code.sajari.com/env"
 )
 
+// Options configures ParseWithOptions.  The zero value uses the defaults for
+// the process: env.CmdVar, flag.CommandLine, os.Stderr and os.Exit.
+type Options struct {
+	// Output is where errors are written.  Defaults to os.Stderr.
+	Output io.Writer
+
+	// DumpOutput is where variable dumps are written, unless -env-dump-file
+	// is set.  Defaults to os.Stdout.
+	DumpOutput io.Writer
+
+	// Input is where answers to the prompts of -env-init are read from.
+	// Defaults to os.Stdin.
+	Input io.Reader
+
+	// Exit is called with the exit code when the process should exit.
+	// Defaults to os.Exit.
+	Exit func(int)
+
+	// VarSet is the variable set to parse.  Defaults to env.CmdVar.
+	VarSet *env.VarSet
+
+	// FlagSet is where the command line arguments are registered.
+	// Defaults to flag.CommandLine.
+	FlagSet *flag.FlagSet
+
+	// Args are the command line arguments parsed by FlagSet.
+	// Defaults to os.Args[1:].
+	Args []string
+
+	// Getter provides the values of variables.  Defaults to the process
+	// environment.
+	Getter env.Getter
+
+	// Logger, if non-nil, is set as the logger for warnings from VarSet.
+	Logger *slog.Logger
+}
+
+func (o *Options) setDefaults() {
+	if o.Output == nil {
+		o.Output = os.Stderr
+	}
+	if o.DumpOutput == nil {
+		o.DumpOutput = os.Stdout
+	}
+	if o.Input == nil {
+		o.Input = os.Stdin
+	}
+	if o.Exit == nil {
+		o.Exit = os.Exit
+	}
+	if o.VarSet == nil {
+		o.VarSet = env.CmdVar
+	}
+	if o.FlagSet == nil {
+		o.FlagSet = flag.CommandLine
+	}
+	if o.Args == nil {
+		o.Args = os.Args[1:]
+	}
+	if o.Getter == nil {
+		o.Getter = env.OSEnv
+	}
+	if o.Logger != nil {
+		o.VarSet.SetLogger(slogLogger{o.Logger})
+	}
+}
+
+// slogLogger adapts a *slog.Logger to env.Logger.
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (l slogLogger) Printf(format string, v ...interface{}) {
+	l.l.Warn(fmt.Sprintf(format, v...))
+}
+
+// writeDump writes the output of dump to the named file, or the dump output
+// if name is empty.
+func (o *Options) writeDump(dump dumpFunc, name string) error {
+	var buf bytes.Buffer
+	if err := dump(&buf, o.VarSet, o.Getter); err != nil {
+		return err
+	}
+	if name != "" {
+		return writeFileAtomic(name, buf.Bytes())
+	}
+	_, err := o.DumpOutput.Write(buf.Bytes())
+	return err
+}
+
 // Parse is equivalent to ParseWithExitFn(os.Exit).
 func Parse() {
 	ParseWithExitFn(os.Exit)
 }
 
-// ParseWithExitFn registers command line arguments -env-check, -env-dump, -env-dump-yaml
-// and calls env.Parse().  Any errors are written to stderr and then exitFn(1) is called.
+// ParseWithExitFn is equivalent to ParseWithOptions with Options.Exit set to exitFn.
+func ParseWithExitFn(exitFn func(int)) {
+	ParseWithOptions(Options{Exit: exitFn})
+}
+
+// ParseWithOptions registers command line arguments -env-check, -env-dump, -env-dump-yaml
+// and calls Parse on the VarSet.  Any errors are written to the output and then exit(1)
+// is called.
 //
 // Registered flags:
-// -env-dump: skips parsing step and writes each env.Var to stderr, calls exitFn(0) when done.
-// -env-dump-yaml: skips parsing steps and write each env.Var to stderr in YAML format, calls
-// os.Exit(0) when done.
-// -env-check: calls exitFn(0) if env.Parse() succeeds without error.
-func ParseWithExitFn(exitFn func(int)) {
-	envCheck := flag.Bool("env-check", false, "check env variables")
-	envDump := flag.Bool("env-dump", false, "dump env variables")
-	envDumpYAML := flag.Bool("env-dump-yaml", false, "dump env variables in YAML format")
-	envDumpJSON := flag.Bool("env-dump-json", false, "dump env variables in JSON format")
-	envDumpCUE := flag.Bool("env-dump-cue", false, "dump env variables as CUE schema")
-
-	flag.Parse()
-
-	var outWriter io.Writer = os.Stderr
-
-	if *envDumpJSON {
-		fmt.Fprintf(outWriter, "{\n")
-		first := true
-		env.Visit(func(v *env.Var) {
-			if !first {
-				fmt.Fprintf(outWriter, ",\n")
-			}
-			first = false
-			fmt.Fprintf(outWriter, "    %q: %q", v.Name, os.Getenv(v.Name))
-		})
-		fmt.Fprintf(outWriter, "\n}\n")
-		exitFn(0)
-	}
-
-	if *envDumpYAML {
-		env.Visit(func(v *env.Var) {
-			fmt.Fprintf(outWriter, "- name: %v\n  value: %q\n", v.Name, os.Getenv(v.Name))
-		})
-		exitFn(0)
-	}
-
-	if *envDumpCUE {
-		fmt.Fprintf(outWriter, "package %s\n\n", env.CmdName())
-		fmt.Fprintf(outWriter, "#Env: [string]: string")
-		env.Visit(func(v *env.Var) {
-			// Insert newlines between fields to avoid cue fmt issues
-			fmt.Fprintf(outWriter, "\n\n#Env: \"%v\": string", v.Name)
-		})
-		fmt.Fprintln(outWriter, "")
-		exitFn(0)
-	}
-
-	if *envDump {
-		first := true
-		env.Visit(func(v *env.Var) {
-			if !first {
-				fmt.Fprintf(outWriter, "\n")
-			}
-			first = false
-			fmt.Fprintf(outWriter, "# %v\nexport %v=%q\n", v.Usage, v.Name, os.Getenv(v.Name))
-		})
-		exitFn(0)
-	}
-
-	if err := env.Parse(); err != nil {
-		if es, ok := err.(env.Errors); ok {
-			for _, e := range es {
-				fmt.Fprintln(outWriter, e)
-			}
-		} else {
-			fmt.Fprintln(outWriter, err)
+// -env-dump: skips parsing step and writes each env.Var to the dump output, calls exit(0) when done.
+// Variables which are not set are written with their example value (see env.Example), or otherwise
+// their default.
+// -env-dump-yaml: skips parsing steps and write each env.Var to the dump output in YAML format, calls
+// exit(0) when done.
+// -env-dump-k8s: skips parsing steps and writes the env list of a Kubernetes container spec
+// to the dump output, calls exit(0) when done.
+// -env-dump-k8s-config: as -env-dump-k8s, but writes a Kubernetes ConfigMap and Secret.
+// -env-dump-k8s-envfrom: as -env-dump-k8s, but writes the envFrom list of a Kubernetes
+// container spec referencing the ConfigMap and Secret written by -env-dump-k8s-config.
+// -env-dump-compose, -env-dump-systemd, -env-dump-docker, -env-dump-helm: as -env-dump, but
+// written as a docker-compose environment map, systemd EnvironmentFile, docker run --env-file
+// file or Helm values.yaml fragment respectively.
+// -env-docs: skips parsing steps and writes a reference table of the variables (see
+// env.VarSet.WriteDocs) to the dump output in the format given by -env-docs-format, calls
+// exit(0) when done.
+// -env-init: skips parsing steps and prompts for the value of each variable on the input
+// (writing prompts to the output), validating each answer, then writes them to the dump output
+// in the same format as -env-dump, calls exit(0) when done.  Answers are echoed as they are
+// typed, including those for secret variables.
+// -env-diff: skips parsing steps and writes the differences between the values of the
+// variables and those in the named dotenv or JSON file (see env.VarSet.Diff) to the dump output.
+// Calls exit(0) if there are no differences, exit(1) if there are, and exit(2) if the file
+// can't be read.
+// -env-dump-file: writes dumps to the named file instead of the dump output.  The file is replaced
+// atomically, and only once the dump is complete.
+// -env-check: also writes any prefixed environment variables which are not used by the VarSet
+// (see env.VarSet.Unclaimed) to the output, and if parsing succeeds without error writes the
+// fingerprint of the variables (see env.VarSet.Fingerprint) to the dump output and calls exit(0).
+// -env-check-file: as -env-check, but checks the variables in the named dotenv or JSON file
+// instead of the environment (see env.VarSet.Check), without changing their values.  Calls exit(2)
+// if the file can't be read.
+// -env-check-format: with -env-check or -env-check-file, writes a report of the problems found, with the name, kind
+// (see env.ErrorKind), value (redacted if secret) and message of each, to the dump output (or
+// -env-dump-file) in the given format instead: json, sarif (SARIF 2.1.0) or junit (JUnit XML).
+func ParseWithOptions(o Options) {
+	o.setDefaults()
+
+	envCheck := o.FlagSet.Bool("env-check", false, "check env variables")
+	envCheckFile := o.FlagSet.String("env-check-file", "", "check the env variables in a dotenv or JSON `file`, without starting")
+	envCheckFormat := o.FlagSet.String("env-check-format", "text", "`format` of -env-check: text, json, sarif or junit")
+	envDump := o.FlagSet.Bool("env-dump", false, "dump env variables")
+	envDumpYAML := o.FlagSet.Bool("env-dump-yaml", false, "dump env variables in YAML format")
+	envDumpJSON := o.FlagSet.Bool("env-dump-json", false, "dump env variables in JSON format")
+	envDumpCUE := o.FlagSet.Bool("env-dump-cue", false, "dump env variables as CUE schema")
+	envDumpJSONSchema := o.FlagSet.Bool("env-dump-jsonschema", false, "dump env variables as JSON Schema")
+	envDumpK8s := o.FlagSet.Bool("env-dump-k8s", false, "dump env variables as the env list of a Kubernetes container spec")
+	envDumpK8sConfig := o.FlagSet.Bool("env-dump-k8s-config", false, "dump env variables as a Kubernetes ConfigMap and Secret")
+	envDumpK8sEnvFrom := o.FlagSet.Bool("env-dump-k8s-envfrom", false, "dump the envFrom list of a Kubernetes container spec referencing the ConfigMap and Secret")
+	envDumpCompose := o.FlagSet.Bool("env-dump-compose", false, "dump env variables as a docker-compose environment map")
+	envDumpSystemd := o.FlagSet.Bool("env-dump-systemd", false, "dump env variables as a systemd EnvironmentFile")
+	envDumpDocker := o.FlagSet.Bool("env-dump-docker", false, "dump env variables as a docker run --env-file file")
+	envDumpHelm := o.FlagSet.Bool("env-dump-helm", false, "dump env variables as a Helm values.yaml fragment")
+	envDocs := o.FlagSet.Bool("env-docs", false, "write a reference table of env variables")
+	envDocsFormat := o.FlagSet.String("env-docs-format", string(env.Markdown), "`format` of -env-docs: markdown or html")
+	envK8sName := o.FlagSet.String("env-k8s-name", "", "`name` of the Kubernetes ConfigMap and Secret (defaults to the command name)")
+	envK8sNamespace := o.FlagSet.String("env-k8s-namespace", "", "`namespace` of the Kubernetes ConfigMap and Secret")
+	envInit := o.FlagSet.Bool("env-init", false, "prompt for the value of each env variable, and dump them")
+	envDiff := o.FlagSet.String("env-diff", "", "compare env variables with a dotenv or JSON `file`")
+	envDumpFile := o.FlagSet.String("env-dump-file", "", "write env variable dumps to `file` instead of stdout")
+
+	if err := o.FlagSet.Parse(o.Args); err != nil {
+		// Only reached when FlagSet uses flag.ContinueOnError.
+		o.Exit(2)
+		return
+	}
+
+	vs := o.VarSet
+
+	var report func(io.Writer, checkReport) error
+	if *envCheckFormat != "text" {
+		report = checkFormats[*envCheckFormat]
+		if report == nil {
+			fmt.Fprintf(o.Output, "unknown -env-check-format %q\n", *envCheckFormat)
+			o.Exit(2)
+			return
+		}
+	}
+
+	var dump dumpFunc
+	switch {
+	case *envDumpJSON:
+		dump = dumpJSON
+	case *envDumpYAML:
+		dump = dumpYAML
+	case *envDumpCUE:
+		dump = dumpCUE
+	case *envDumpJSONSchema:
+		dump = dumpJSONSchema
+	case *envDumpK8s, *envDumpK8sConfig, *envDumpK8sEnvFrom:
+		k8s := K8sOptions{Name: *envK8sName, Namespace: *envK8sNamespace}
+		write := WriteK8sEnv
+		switch {
+		case *envDumpK8sConfig:
+			write = WriteK8sConfig
+		case *envDumpK8sEnvFrom:
+			write = WriteK8sEnvFrom
+		}
+		dump = func(w io.Writer, vs *env.VarSet, g env.Getter) error {
+			k8s.Getter = g
+			return write(w, vs, k8s)
+		}
+	case *envDumpCompose:
+		dump = dumpCompose
+	case *envDumpSystemd:
+		dump = dumpSystemd
+	case *envDumpDocker:
+		dump = dumpDocker
+	case *envDumpHelm:
+		dump = dumpHelm
+	case *envDocs:
+		dump = func(w io.Writer, vs *env.VarSet, _ env.Getter) error {
+			return vs.WriteDocs(w, env.DocsFormat(*envDocsFormat))
+		}
+	case *envInit:
+		dump = func(w io.Writer, vs *env.VarSet, g env.Getter) error {
+			return initEnv(w, vs, g, bufio.NewReader(o.Input), o.Output)
+		}
+	case *envDump:
+		dump = dumpEnv
+	}
+
+	if *envDiff != "" {
+		snapshot, err := env.ReadFile(*envDiff)
+		if err != nil {
+			fmt.Fprintln(o.Output, err)
+			o.Exit(2)
+			return
+		}
+		changes := vs.Diff(snapshot, o.Getter)
+		writeDiff(o.DumpOutput, changes)
+		if len(changes) > 0 {
+			o.Exit(1)
+			return
+		}
+		o.Exit(0)
+		return
+	}
+
+	if dump != nil {
+		if err := o.writeDump(dump, *envDumpFile); err != nil {
+			fmt.Fprintln(o.Output, err)
+			o.Exit(1)
+			return
+		}
+		o.Exit(0)
+		return
+	}
+
+	// -env-check-file checks the file instead of parsing the environment.
+	g, check, parse := o.Getter, *envCheck, vs.Parse
+	if *envCheckFile != "" {
+		snapshot, err := env.ReadFile(*envCheckFile)
+		if err != nil {
+			fmt.Fprintln(o.Output, err)
+			o.Exit(2)
+			return
 		}
-		exitFn(1)
+		g, check, parse = snapshot, true, vs.Check
 	}
 
-	if *envCheck {
-		exitFn(0)
+	err := parse(g)
+	var errs env.Errors
+	if err != nil {
+		var ok bool
+		if errs, ok = err.(env.Errors); !ok {
+			errs = env.Errors{err}
+		}
+		if *envCheckFile == "" {
+			addParseErrors(vs, len(errs))
+		}
+	}
+	var unclaimed []env.UnclaimedVar
+	if check && !vs.Strict() {
+		// In strict mode these are already included in err.
+		unclaimed = vs.Unclaimed(g)
+	}
+
+	// The fingerprint is of the values which were parsed, so is only
+	// reported for the environment.
+	var fingerprint string
+	if err == nil && *envCheckFile == "" {
+		fingerprint = vs.Fingerprint()
+	}
+
+	if check && report != nil {
+		r := newCheckReport(vs, errs, unclaimed)
+		r.fingerprint = fingerprint
+		dump := func(w io.Writer, _ *env.VarSet, _ env.Getter) error {
+			return report(w, r)
+		}
+		if err := o.writeDump(dump, *envDumpFile); err != nil {
+			fmt.Fprintln(o.Output, err)
+			o.Exit(1)
+			return
+		}
+		if !r.ok {
+			o.Exit(1)
+			return
+		}
+		o.Exit(0)
+		return
+	}
+
+	for _, e := range errs {
+		fmt.Fprintln(o.Output, e)
+	}
+	for _, u := range unclaimed {
+		fmt.Fprintln(o.Output, u)
+	}
+	if err != nil {
+		o.Exit(1)
+		return
+	}
+
+	if check {
+		if fingerprint != "" {
+			fmt.Fprintf(o.DumpOutput, "fingerprint %v\n", fingerprint)
+		}
+		o.Exit(0)
 	}
 }
@@ -0,0 +1,644 @@
+package envsvc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"code.sajari.com/env"
+	"code.sajari.com/env/envsvc"
+)
+
+// parse runs envsvc.ParseWithOptions on vs with the given Getter (or the
+// process environment if nil) and arguments, returning the dump output,
+// error output and exit code (-1 if exit was not called).
+func parse(t *testing.T, vs *env.VarSet, g env.Getter, args ...string) (string, string, int) {
+	t.Helper()
+
+	var dump, out bytes.Buffer
+	code := -1
+	envsvc.ParseWithOptions(envsvc.Options{
+		Output:     &out,
+		DumpOutput: &dump,
+		Exit:       func(c int) { code = c },
+		VarSet:     vs,
+		FlagSet:    flag.NewFlagSet("test", flag.ContinueOnError),
+		Args:       args,
+		Getter:     g,
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	return dump.String(), out.String(), code
+}
+
+func TestParseWithOptions(t *testing.T) {
+	vs := env.NewVarSet("envsvc-test-missing")
+	vs.String("NAME", "name test")
+	vs.Int("WORKERS", "workers test")
+
+	_, out, code := parse(t, vs, nil, "-env-check")
+	if code != 1 {
+		t.Errorf("exit code = %d, expected 1", code)
+	}
+	want := "missing env ENVSVC_TEST_MISSING_NAME\nmissing env ENVSVC_TEST_MISSING_WORKERS\n"
+	if out != want {
+		t.Errorf("output = %q, expected %q", out, want)
+	}
+}
+
+func TestParseWithOptionsDump(t *testing.T) {
+	t.Setenv("ENVSVC_TEST_NAME", "name")
+
+	vs := env.NewVarSet("envsvc-test")
+	vs.String("NAME", "name test")
+
+	dump, out, code := parse(t, vs, nil, "-env-dump")
+	if code != 0 {
+		t.Errorf("exit code = %d, expected 0", code)
+	}
+	if out != "" {
+		t.Errorf("error output = %q, expected none", out)
+	}
+	want := "# name test\nexport ENVSVC_TEST_NAME=\"name\"\n"
+	if dump != want {
+		t.Errorf("dump output = %q, expected %q", dump, want)
+	}
+}
+
+func TestParseWithOptionsDumpExample(t *testing.T) {
+	vs := env.NewVarSet("envsvc-test")
+	vs.Int("WORKERS", "workers test", env.Default("4"), env.Example("8"))
+	vs.String("LEVEL", "level test", env.Default("info"))
+	vs.String("NAME", "name test")
+
+	dump, _, code := parse(t, vs, testGetter{"ENVSVC_TEST_NAME": "name"}, "-env-dump")
+	if code != 0 {
+		t.Errorf("exit code = %d, expected 0", code)
+	}
+	want := `# workers test
+export ENVSVC_TEST_WORKERS="8"
+
+# level test
+export ENVSVC_TEST_LEVEL="info"
+
+# name test
+export ENVSVC_TEST_NAME="name"
+`
+	if dump != want {
+		t.Errorf("dump output = %q, expected %q", dump, want)
+	}
+}
+
+func TestParseWithOptionsDumpFile(t *testing.T) {
+	t.Setenv("ENVSVC_TEST_NAME", "name")
+
+	vs := env.NewVarSet("envsvc-test")
+	vs.String("NAME", "name test")
+
+	name := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(name, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dump, _, code := parse(t, vs, nil, "-env-dump-json", "-env-dump-file", name)
+	if code != 0 {
+		t.Errorf("exit code = %d, expected 0", code)
+	}
+	if dump != "" {
+		t.Errorf("dump output = %q, expected none", dump)
+	}
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n    \"ENVSVC_TEST_NAME\": \"name\"\n}\n"
+	if string(b) != want {
+		t.Errorf("file contents = %q, expected %q", b, want)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("found %d files in directory, expected 1", len(entries))
+	}
+}
+
+func TestParseWithOptionsDumpCUE(t *testing.T) {
+	vs := env.NewVarSet("my-svc")
+	vs.BindAddr("LISTEN", "bind address")
+	vs.Int("WORKERS", "number of workers", env.Default("4"), env.Min(1), env.Max(64))
+	vs.Float64("RATIO", "sample ratio", env.Optional())
+	vs.Bool("DEBUG", "debug mode", env.Default("false"))
+	vs.Duration("TIMEOUT", "request timeout", env.Deprecated("use MY_SVC_DEADLINE"))
+	vs.String("LEVEL", "log level", env.OneOf("debug", "info"), env.Default("info"))
+	vs.String("ID", "instance id", env.Pattern(`^[a-z]+$`))
+	vs.Int("PORT", "port", env.Pattern(`^[0-9]{4}$`))
+	vs.Bool("TRACE", "trace mode", env.Pattern(`^(true|false)$`), env.Optional())
+	vs.Var(new(sizeValue), "SIZE", "cache size", env.Min(1), env.Optional())
+
+	dump, _, code := parse(t, vs, nil, "-env-dump-cue")
+	if code != 0 {
+		t.Errorf("exit code = %d, expected 0", code)
+	}
+
+	want := `package my_svc
+
+#Env: [string]: string | number | bool
+
+// bind address
+#Env: "MY_SVC_LISTEN": string
+
+// number of workers
+#Env: "MY_SVC_WORKERS"?: *4 | int & >=1 & <=64
+
+// sample ratio
+#Env: "MY_SVC_RATIO"?: float
+
+// debug mode
+#Env: "MY_SVC_DEBUG"?: *false | bool
+
+// request timeout
+// Deprecated: use MY_SVC_DEADLINE
+#Env: "MY_SVC_TIMEOUT": string & =~"^[-+]?(0|(([0-9]+(\\.[0-9]*)?|\\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+)$"
+
+// log level
+#Env: "MY_SVC_LEVEL"?: *"info" | "debug" | "info"
+
+// instance id
+#Env: "MY_SVC_ID": string & =~"^[a-z]+$"
+
+// port
+#Env: "MY_SVC_PORT": int
+
+// trace mode
+#Env: "MY_SVC_TRACE"?: bool
+
+// cache size
+#Env: "MY_SVC_SIZE"?: string
+`
+	if dump != want {
+		t.Errorf("dump output =\n%v\nexpected\n%v", dump, want)
+	}
+}
+
+// sizeValue is a Value which doesn't implement env.Typed.
+type sizeValue string
+
+func (v *sizeValue) String() string     { return string(*v) }
+func (v *sizeValue) Set(x string) error { *v = sizeValue(x); return nil }
+
+func TestWriteJSONSchema(t *testing.T) {
+	vs := env.NewVarSet("my-svc")
+	vs.BindAddr("LISTEN", "bind address")
+	vs.Int("WORKERS", "number of workers", env.Default("4"), env.Min(1), env.Max(64))
+	vs.String("LEVEL", "log level", env.OneOf("debug", "info"), env.Optional())
+	vs.Float64("RATE", "sample rate", env.OneOf("0.5", "1"), env.Optional())
+	vs.Bool("TRACE", "enable tracing", env.Optional())
+
+	var buf bytes.Buffer
+	if err := envsvc.WriteJSONSchema(&buf, vs); err != nil {
+		t.Fatalf("WriteJSONSchema() = %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("could not unmarshal schema: %v", err)
+	}
+	want := map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "my-svc",
+		"type":    "object",
+		"properties": map[string]interface{}{
+			"MY_SVC_LISTEN": map[string]interface{}{
+				"description": "bind address",
+				"type":        "string",
+			},
+			"MY_SVC_WORKERS": map[string]interface{}{
+				"description": "number of workers",
+				"type":        []interface{}{"integer", "string"},
+				"pattern":     `^[-+]?[0-9]+$`,
+				"default":     4.0,
+				"minimum":     1.0,
+				"maximum":     64.0,
+			},
+			"MY_SVC_LEVEL": map[string]interface{}{
+				"description": "log level",
+				"type":        "string",
+				"enum":        []interface{}{"debug", "info"},
+			},
+			"MY_SVC_RATE": map[string]interface{}{
+				"description": "sample rate",
+				"type":        []interface{}{"number", "string"},
+				"pattern":     `^[-+]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][-+]?[0-9]+)?$`,
+				"enum":        []interface{}{0.5, "0.5", 1.0, "1"},
+			},
+			"MY_SVC_TRACE": map[string]interface{}{
+				"description": "enable tracing",
+				"type":        []interface{}{"boolean", "string"},
+				"pattern":     `^(1|t|T|TRUE|true|True|0|f|F|FALSE|false|False)$`,
+			},
+		},
+		"required": []interface{}{"MY_SVC_LISTEN"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WriteJSONSchema() wrote\n%s\nexpected\n%#v", buf.Bytes(), want)
+	}
+
+	// The string values written by -env-dump-json match the patterns.
+	g := env.Map{"MY_SVC_LISTEN": ":80", "MY_SVC_WORKERS": "8", "MY_SVC_RATE": "1", "MY_SVC_TRACE": "true"}
+	dump, _, _ := parse(t, vs, g, "-env-dump-json")
+	var values map[string]string
+	if err := json.Unmarshal([]byte(dump), &values); err != nil {
+		t.Fatalf("invalid dump %q: %v", dump, err)
+	}
+	props := got["properties"].(map[string]interface{})
+	for k, v := range values {
+		if p, ok := props[k].(map[string]interface{})["pattern"].(string); ok && !regexp.MustCompile(p).MatchString(v) {
+			t.Errorf("dumped %v=%q doesn't match schema pattern %q", k, v, p)
+		}
+	}
+}
+
+type testGetter map[string]string
+
+func (g testGetter) Get(x string) (string, bool) {
+	v, ok := g[x]
+	return v, ok
+}
+
+func TestWriteK8s(t *testing.T) {
+	vs := env.NewVarSet("My_Svc")
+	vs.String("NAME", "name")
+	vs.String("API_KEY", "api key", env.Secret())
+
+	o := envsvc.K8sOptions{
+		Namespace: "prod",
+		Getter:    testGetter{"MY_SVC_NAME": "a \"quoted\"\x00 name", "MY_SVC_API_KEY": "secret"},
+	}
+
+	var buf bytes.Buffer
+	if err := envsvc.WriteK8sEnv(&buf, vs, o); err != nil {
+		t.Fatalf("WriteK8sEnv() = %v", err)
+	}
+	want := `env:
+  - name: MY_SVC_NAME
+    value: "a \"quoted\"\0 name"
+  - name: MY_SVC_API_KEY
+    valueFrom:
+      secretKeyRef:
+        name: my-svc
+        key: MY_SVC_API_KEY
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteK8sEnv() wrote\n%v\nexpected\n%v", got, want)
+	}
+
+	buf.Reset()
+	if err := envsvc.WriteK8sConfig(&buf, vs, o); err != nil {
+		t.Fatalf("WriteK8sConfig() = %v", err)
+	}
+	want = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-svc
+  namespace: prod
+data:
+  MY_SVC_NAME: "a \"quoted\"\0 name"
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: my-svc
+  namespace: prod
+type: Opaque
+stringData:
+  MY_SVC_API_KEY: secret
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteK8sConfig() wrote\n%v\nexpected\n%v", got, want)
+	}
+
+	buf.Reset()
+	if err := envsvc.WriteK8sEnvFrom(&buf, vs, o); err != nil {
+		t.Fatalf("WriteK8sEnvFrom() = %v", err)
+	}
+	want = `envFrom:
+  - configMapRef:
+      name: my-svc
+  - secretRef:
+      name: my-svc
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteK8sEnvFrom() wrote\n%v\nexpected\n%v", got, want)
+	}
+
+	g := env.Map{"MY_SVC_NAME": "name", "MY_SVC_API_KEY": "secret"}
+	if dump, _, _ := parse(t, vs, g, "-env-dump-k8s-envfrom", "-env-k8s-name", "svc"); !strings.Contains(dump, "- secretRef:\n      name: svc\n") {
+		t.Errorf("-env-dump-k8s-envfrom wrote\n%v\nexpected a secretRef to svc", dump)
+	}
+}
+
+func TestParseWithOptionsDiff(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "prod.env")
+	snapshot := "export ENVSVC_DIFF_NAME=\"prod\"\nexport ENVSVC_DIFF_KEY=\"prod-key\"\n"
+	if err := os.WriteFile(name, []byte(snapshot), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	vs := env.NewVarSet("envsvc-diff")
+	vs.String("NAME", "name test")
+	vs.String("KEY", "key test", env.Secret())
+
+	g := env.Map{"ENVSVC_DIFF_NAME": "staging", "ENVSVC_DIFF_KEY": "staging-key", "ENVSVC_DIFF_TYPO": "1"}
+	dump, _, code := parse(t, vs, g, "-env-diff", name)
+	if code != 1 {
+		t.Errorf("exit code = %d, expected 1", code)
+	}
+	want := `changed ENVSVC_DIFF_NAME: "prod" -> "staging"
+changed ENVSVC_DIFF_KEY: <redacted> -> <redacted>
+unknown ENVSVC_DIFF_TYPO: (unset) -> "1"
+`
+	if dump != want {
+		t.Errorf("dump output =\n%v\nexpected\n%v", dump, want)
+	}
+}
+
+func TestParseWithOptionsCheckUnclaimed(t *testing.T) {
+	vs := env.NewVarSet("envsvc-check")
+	vs.Int("WORKERS", "workers test")
+
+	g := env.Map{"ENVSVC_CHECK_WORKERS": "4", "ENVSVC_CHECK_WORKRES": "4"}
+	dump, out, code := parse(t, vs, g, "-env-check")
+	if code != 0 {
+		t.Errorf("exit code = %d, expected 0", code)
+	}
+	if want := "fingerprint " + vs.Fingerprint() + "\n"; dump != want {
+		t.Errorf("dump output = %q, expected %q", dump, want)
+	}
+	want := "unknown env ENVSVC_CHECK_WORKRES (did you mean ENVSVC_CHECK_WORKERS?)\n"
+	if out != want {
+		t.Errorf("output = %q, expected %q", out, want)
+	}
+
+	vs.SetStrict(true)
+	dump, out, code = parse(t, vs, g, "-env-check")
+	if code != 1 {
+		t.Errorf("strict: exit code = %d, expected 1", code)
+	}
+	if dump != "" {
+		t.Errorf("strict: dump output = %q, expected none", dump)
+	}
+	if out != want {
+		t.Errorf("strict: output = %q, expected %q", out, want)
+	}
+}
+
+func TestParseWithOptionsCheckFormat(t *testing.T) {
+	vs := env.NewVarSet("envsvc-check")
+	vs.String("NAME", "name test")
+	vs.Int("WORKERS", "workers test", env.Max(8))
+	vs.String("KEY", "key test", env.Secret(), env.Pattern("^[a-z]+$"))
+	vs.String("LEVEL", "level test", env.Optional())
+
+	g := env.Map{
+		"ENVSVC_CHECK_WORKERS": "16",
+		"ENVSVC_CHECK_KEY":     "S3CRET",
+		"ENVSVC_CHECK_LEVLE":   "debug",
+	}
+
+	dump, out, code := parse(t, vs, g, "-env-check", "-env-check-format", "json")
+	if code != 1 {
+		t.Errorf("json: exit code = %d, expected 1", code)
+	}
+	if out != "" {
+		t.Errorf("json: output = %q, expected none", out)
+	}
+	var report struct {
+		OK       bool `json:"ok"`
+		Problems []struct {
+			Name, Kind, Value, Message, Severity string
+		} `json:"problems"`
+	}
+	if err := json.Unmarshal([]byte(dump), &report); err != nil {
+		t.Fatalf("json: invalid report %q: %v", dump, err)
+	}
+	type problem struct{ Name, Kind, Value, Severity string }
+	var got []problem
+	for _, p := range report.Problems {
+		if p.Message == "" {
+			t.Errorf("json: problem %+v has no message", p)
+		}
+		got = append(got, problem{p.Name, p.Kind, p.Value, p.Severity})
+	}
+	want := []problem{
+		{"ENVSVC_CHECK_NAME", "missing", "", "error"},
+		{"ENVSVC_CHECK_WORKERS", "constraint", "16", "error"},
+		{"ENVSVC_CHECK_KEY", "constraint", "<redacted>", "error"},
+		{"ENVSVC_CHECK_LEVLE", "unknown", "", "warning"},
+	}
+	if report.OK || !reflect.DeepEqual(got, want) {
+		t.Errorf("json: report ok %v with problems\n%+v\nexpected false with\n%+v", report.OK, got, want)
+	}
+	if strings.Contains(dump, "S3CRET") {
+		t.Errorf("json: report contains secret value")
+	}
+
+	dump, _, code = parse(t, vs, g, "-env-check", "-env-check-format", "sarif")
+	if code != 1 {
+		t.Errorf("sarif: exit code = %d, expected 1", code)
+	}
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					LogicalLocations []struct {
+						Name string `json:"name"`
+					} `json:"logicalLocations"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(dump), &sarif); err != nil {
+		t.Fatalf("sarif: invalid report %q: %v", dump, err)
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 || len(sarif.Runs[0].Results) != 4 {
+		t.Fatalf("sarif: report %v, expected version 2.1.0 with 4 results", dump)
+	}
+	if r := sarif.Runs[0].Results[1]; r.RuleID != "constraint" || r.Level != "error" || r.Locations[0].LogicalLocations[0].Name != "ENVSVC_CHECK_WORKERS" {
+		t.Errorf("sarif: result %+v, expected constraint error for ENVSVC_CHECK_WORKERS", r)
+	}
+
+	dump, _, code = parse(t, vs, g, "-env-check", "-env-check-format", "junit")
+	if code != 1 {
+		t.Errorf("junit: exit code = %d, expected 1", code)
+	}
+	var junit struct {
+		Suites []struct {
+			Name      string `xml:"name,attr"`
+			Tests     int    `xml:"tests,attr"`
+			Failures  int    `xml:"failures,attr"`
+			TestCases []struct {
+				Name    string `xml:"name,attr"`
+				Failure *struct {
+					Type string `xml:"type,attr"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+			SystemErr string `xml:"system-err"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal([]byte(dump), &junit); err != nil {
+		t.Fatalf("junit: invalid report %q: %v", dump, err)
+	}
+	if len(junit.Suites) != 1 {
+		t.Fatalf("junit: report %v, expected one test suite", dump)
+	}
+	s := junit.Suites[0]
+	if s.Name != "envsvc-check" || s.Tests != 4 || s.Failures != 3 || !strings.Contains(s.SystemErr, "ENVSVC_CHECK_LEVLE") {
+		t.Errorf("junit: test suite %+v, expected 4 tests with 3 failures and a warning", s)
+	}
+	if tc := s.TestCases[3]; tc.Name != "ENVSVC_CHECK_LEVEL" || tc.Failure != nil {
+		t.Errorf("junit: test case %+v, expected ENVSVC_CHECK_LEVEL to pass", tc)
+	}
+
+	g = env.Map{"ENVSVC_CHECK_NAME": "name", "ENVSVC_CHECK_WORKERS": "4", "ENVSVC_CHECK_KEY": "secret"}
+	dump, _, code = parse(t, vs, g, "-env-check", "-env-check-format", "json")
+	if code != 0 {
+		t.Errorf("json: exit code = %d, expected 0", code)
+	}
+	want2 := "{\n    \"ok\": true,\n    \"fingerprint\": \"" + vs.Fingerprint() + "\",\n    \"problems\": []\n}\n"
+	if dump != want2 {
+		t.Errorf("json: report %q, expected %q", dump, want2)
+	}
+
+	_, out, code = parse(t, vs, g, "-env-check", "-env-check-format", "xml")
+	if code != 2 || out != "unknown -env-check-format \"xml\"\n" {
+		t.Errorf("unknown format: exit code %d with output %q, expected 2 and an error", code, out)
+	}
+}
+
+func TestParseWithOptionsCheckShortSecret(t *testing.T) {
+	vs := env.NewVarSet("envsvc-secret")
+	vs.String("TOKEN", "a token", env.Secret(), env.Pattern("^[0-9]+$"))
+	vs.Int("PIN", "a pin", env.Secret())
+
+	// Short values appear in the names, so mustn't be replaced.
+	g := env.Map{"ENVSVC_SECRET_TOKEN": "E", "ENVSVC_SECRET_PIN": "S"}
+	want := []string{
+		`could not set env ENVSVC_SECRET_TOKEN: value does not satisfy constraints: matches "^[0-9]+$"`,
+		"could not set env ENVSVC_SECRET_PIN: invalid value",
+	}
+
+	dump, _, code := parse(t, vs, g, "-env-check", "-env-check-format", "sarif")
+	if code != 1 {
+		t.Errorf("sarif: exit code = %d, expected 1", code)
+	}
+	var sarif struct {
+		Runs []struct {
+			Results []struct {
+				Message struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Properties map[string]string `json:"properties"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(dump), &sarif); err != nil {
+		t.Fatalf("sarif: invalid report %q: %v", dump, err)
+	}
+	var got []string
+	for _, r := range sarif.Runs[0].Results {
+		got = append(got, r.Message.Text)
+		if v := r.Properties["value"]; v != "<redacted>" {
+			t.Errorf("sarif: value %q, expected <redacted>", v)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sarif: messages\n%q\nexpected\n%q", got, want)
+	}
+
+	dump, _, code = parse(t, vs, g, "-env-check", "-env-check-format", "junit")
+	if code != 1 {
+		t.Errorf("junit: exit code = %d, expected 1", code)
+	}
+	var junit struct {
+		TestCases []struct {
+			Failure struct {
+				Message string `xml:"message,attr"`
+				Value   string `xml:",chardata"`
+			} `xml:"failure"`
+		} `xml:"testsuite>testcase"`
+	}
+	if err := xml.Unmarshal([]byte(dump), &junit); err != nil {
+		t.Fatalf("junit: invalid report %q: %v", dump, err)
+	}
+	got = nil
+	for _, tc := range junit.TestCases {
+		got = append(got, tc.Failure.Message)
+		if tc.Failure.Value != `value: "<redacted>"` {
+			t.Errorf("junit: failure value %q, expected redacted", tc.Failure.Value)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("junit: messages\n%q\nexpected\n%q", got, want)
+	}
+}
+
+func TestParseWithOptionsCheckFile(t *testing.T) {
+	vs := env.NewVarSet("envsvc-check-file")
+	workers := vs.Int("WORKERS", "workers test", env.Max(8))
+	vs.String("NAME", "name test")
+
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.env")
+	if err := os.WriteFile(bad, []byte("ENVSVC_CHECK_FILE_WORKERS=16\nENVSVC_CHECK_FILE_NAEM=x\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	good := filepath.Join(dir, "good.json")
+	if err := os.WriteFile(good, []byte(`{"ENVSVC_CHECK_FILE_WORKERS": 4, "ENVSVC_CHECK_FILE_NAME": "name"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The environment is ignored.
+	g := env.Map{"ENVSVC_CHECK_FILE_WORKERS": "2", "ENVSVC_CHECK_FILE_NAME": "name"}
+
+	dump, out, code := parse(t, vs, g, "-env-check-file", bad)
+	if code != 1 {
+		t.Errorf("exit code = %d, expected 1", code)
+	}
+	want := "could not set env ENVSVC_CHECK_FILE_WORKERS: 16 is greater than maximum 8\n" +
+		"missing env ENVSVC_CHECK_FILE_NAME\n" +
+		"unknown env ENVSVC_CHECK_FILE_NAEM (did you mean ENVSVC_CHECK_FILE_NAME?)\n"
+	if out != want || dump != "" {
+		t.Errorf("output = %q, dump %q, expected %q and no dump", out, dump, want)
+	}
+
+	dump, out, code = parse(t, vs, g, "-env-check-file", good, "-env-check-format", "json")
+	if code != 0 || out != "" {
+		t.Errorf("exit code = %d with output %q, expected 0 and none", code, out)
+	}
+	if want := "{\n    \"ok\": true,\n    \"problems\": []\n}\n"; dump != want {
+		t.Errorf("dump = %q, expected %q", dump, want)
+	}
+	if *workers != 0 {
+		t.Errorf("-env-check-file set WORKERS to %d, expected it unchanged", *workers)
+	}
+
+	_, out, code = parse(t, vs, g, "-env-check-file", filepath.Join(dir, "missing.env"))
+	if code != 2 || out == "" {
+		t.Errorf("missing file: exit code %d with output %q, expected 2 and an error", code, out)
+	}
+}
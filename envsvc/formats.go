@@ -0,0 +1,89 @@
+package envsvc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"code.sajari.com/env"
+)
+
+// dumpCompose writes the environment map of a docker-compose service.
+// Compose interpolates $VAR in values, so $ is escaped as $$.
+func dumpCompose(w io.Writer, vs *env.VarSet, g env.Getter) error {
+	var m yamlMap
+	vs.Visit(func(v *env.Var) {
+		m = append(m, field{v.Name, strings.Replace(get(v, g), "$", "$$", -1)})
+	})
+	return writeYAML(w, map[string]yamlMap{"environment": m})
+}
+
+// dumpSystemd writes a file for the EnvironmentFile= directive of a systemd
+// unit.  Values are double-quoted, in which systemd only treats \ as an escape
+// for ", \, ` and $.
+func dumpSystemd(w io.Writer, vs *env.VarSet, g env.Getter) error {
+	r := strings.NewReplacer(`"`, `\"`, `\`, `\\`, "`", "\\`", `$`, `\$`)
+	first := true
+	vs.Visit(func(v *env.Var) {
+		if !first {
+			fmt.Fprintf(w, "\n")
+		}
+		first = false
+		writeComment(w, v.Usage)
+		fmt.Fprintf(w, "%v=\"%v\"\n", v.Name, r.Replace(get(v, g)))
+	})
+	return nil
+}
+
+// dumpDocker writes a file for the --env-file option of docker run, in which
+// values are used verbatim: there are no quotes or escapes, so values can't
+// contain newlines.
+func dumpDocker(w io.Writer, vs *env.VarSet, g env.Getter) error {
+	var err error
+	first := true
+	vs.Visit(func(v *env.Var) {
+		z := get(v, g)
+		if strings.ContainsAny(z, "\r\n") {
+			if err == nil {
+				err = fmt.Errorf("env %v contains a newline, which can't be written to a docker env file", v.Name)
+			}
+			return
+		}
+		if !first {
+			fmt.Fprintf(w, "\n")
+		}
+		first = false
+		writeComment(w, v.Usage)
+		fmt.Fprintf(w, "%v=%v\n", v.Name, z)
+	})
+	return err
+}
+
+// dumpHelm writes a fragment of a Helm values.yaml file, with secret
+// variables in secretEnv and all others in env.
+func dumpHelm(w io.Writer, vs *env.VarSet, g env.Getter) error {
+	var vars, secrets yamlMap
+	vs.Visit(func(v *env.Var) {
+		if v.Secret {
+			secrets = append(secrets, field{v.Name, get(v, g)})
+			return
+		}
+		vars = append(vars, field{v.Name, get(v, g)})
+	})
+
+	values := struct {
+		Env       yamlMap `yaml:"env,omitempty"`
+		SecretEnv yamlMap `yaml:"secretEnv,omitempty"`
+	}{vars, secrets}
+	return writeYAML(w, values)
+}
+
+// writeComment writes x as # comments, one per line of x.
+func writeComment(w io.Writer, x string) {
+	if x == "" {
+		return
+	}
+	for _, line := range strings.Split(x, "\n") {
+		fmt.Fprintf(w, "# %v\n", line)
+	}
+}
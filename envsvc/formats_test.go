@@ -0,0 +1,68 @@
+package envsvc
+
+import (
+	"bytes"
+	"testing"
+
+	"code.sajari.com/env"
+)
+
+func TestFormats(t *testing.T) {
+	vs := env.NewVarSet("svc")
+	vs.String("NAME", "name of the service")
+	vs.String("PASSWORD", "database password", env.Secret())
+	g := mapGetter{
+		"SVC_NAME":     `a "$HOME" \ name`,
+		"SVC_PASSWORD": "pa$$word`",
+	}
+
+	tests := []struct {
+		name string
+		dump dumpFunc
+		want string
+	}{
+		{"compose", dumpCompose, `environment:
+  SVC_NAME: a "$$HOME" \ name
+  SVC_PASSWORD: pa$$$$word` + "`" + `
+`},
+		{"systemd", dumpSystemd, `# name of the service
+SVC_NAME="a \"\$HOME\" \\ name"
+
+# database password
+SVC_PASSWORD="pa\$\$word\` + "`" + `"
+`},
+		{"docker", dumpDocker, `# name of the service
+SVC_NAME=a "$HOME" \ name
+
+# database password
+SVC_PASSWORD=pa$$word` + "`" + `
+`},
+		{"helm", dumpHelm, `env:
+  SVC_NAME: a "$HOME" \ name
+secretEnv:
+  SVC_PASSWORD: pa$$word` + "`" + `
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.dump(&buf, vs, g); err != nil {
+				t.Fatalf("dump() = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("dump() wrote\n%v\nexpected\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDumpDockerNewline(t *testing.T) {
+	vs := env.NewVarSet("")
+	vs.String("CERT", "certificate")
+
+	var buf bytes.Buffer
+	if err := dumpDocker(&buf, vs, mapGetter{"CERT": "line 1\nline 2"}); err == nil {
+		t.Errorf("dumpDocker() = nil, expected error for value containing newline")
+	}
+}
@@ -1,61 +1,382 @@
 package envsvc
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"path"
+	"strconv"
+	"strings"
 
 	"code.sajari.com/env"
 )
 
-// Handler returns the env HTTP Handler.
+// Handler returns the env HTTP Handler, configured by opts.  See Register to
+// install it at /debug/env.
 //
-// This is only needed to install the handler in a non-standard location.
-func Handler() http.Handler {
-	return http.HandlerFunc(envHandler)
+// The handler responds to GET and HEAD requests with the variables of
+// env.CmdVar (or the VarSet option) and their values (secret values are
+// redacted).  By default each variable is described by its name, usage,
+// value, type, default, source (the name of the variable or alias it was
+// read from, "default", or "override" if changed using Overrides) and
+// whether it was set, along with the
+// fingerprint of the whole set (see env.VarSet.Fingerprint), which is also
+// in the X-Env-Fingerprint header.  The query parameters are:
+//
+//	prefix=P   only include variables whose names start with P
+//	match=G    only include variables whose names match the glob G (see path.Match)
+//	short      only include the name and value of each variable
+//
+// The format is chosen using the Accept header: JSON (application/json, the
+// default), YAML (application/yaml) or dotenv (text/plain, as -env-dump).
+// Responses include an ETag and "Cache-Control: no-cache", so that clients
+// polling the handler can use If-None-Match to avoid transferring unchanged
+// variables.
+//
+// Requests for a path ending in /metrics are served the metrics written by
+// WriteMetrics.  With the AllowOverrides option, POST requests change the
+// values of reloadable variables (see Overrides).
+func Handler(opts ...HandlerOption) http.Handler {
+	c := handlerConfig{vs: env.CmdVar}
+	for _, o := range opts {
+		o(&c)
+	}
+
+	allow := "GET, HEAD"
+	if c.overrides != nil {
+		allow += ", POST"
+	}
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o := c.overrides
+		metrics := strings.HasSuffix(r.URL.Path, "/metrics")
+		switch {
+		case r.Method == http.MethodPost && o != nil && !metrics:
+			o.serve(w, r, c.vs)
+		case r.Method != http.MethodGet && r.Method != http.MethodHead:
+			if metrics {
+				w.Header().Set("Allow", "GET, HEAD")
+			} else {
+				w.Header().Set("Allow", allow)
+			}
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		case metrics:
+			serveMetrics(w, r, c.vs, o)
+		case o != nil && r.URL.Query().Has("audit"):
+			o.serveAudit(w)
+		case o != nil:
+			// Don't read values while they are being overridden.
+			o.mu.RLock()
+			defer o.mu.RUnlock()
+			serveEnv(w, r, c.vs)
+		default:
+			serveEnv(w, r, c.vs)
+		}
+	})
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+	return h
 }
 
-func envHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+// Register installs Handler(opts...) at /debug/env and /debug/env/metrics on
+// mux, or http.DefaultServeMux if mux is nil.
+//
+// The handler is not installed unless Register is called, as it exposes the
+// configuration of the process.  Unless mux is only served to trusted
+// clients, opts should include an authentication option such as BearerToken,
+// ClientCert or Authorize.
+func Register(mux *http.ServeMux, opts ...HandlerOption) {
+	if mux == nil {
+		mux = http.DefaultServeMux
+	}
+	h := Handler(opts...)
+	mux.Handle("/debug/env", h)
+	mux.Handle("/debug/env/metrics", h)
+}
+
+// Media types served by the handler.
+const (
+	jsonType   = "application/json"
+	yamlType   = "application/yaml"
+	dotenvType = "text/plain"
+)
+
+// envFormats are the media types accepted in the Accept header, in order of
+// preference, and the format used for each.  Alternative names for YAML are
+// last so that wildcards prefer the canonical types.
+var envFormats = []struct {
+	mediaType, format string
+}{
+	{jsonType, jsonType},
+	{yamlType, yamlType},
+	{dotenvType, dotenvType},
+	{"application/x-yaml", yamlType},
+	{"text/yaml", yamlType},
+}
+
+// serveEnv responds to a GET or HEAD request with the variables in vs.
+func serveEnv(w http.ResponseWriter, r *http.Request, vs *env.VarSet) {
+	q := r.URL.Query()
+	match, err := varFilter(q.Get("prefix"), q.Get("match"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var vars []*env.Var
+	vs.Visit(func(v *env.Var) {
+		if match(v) {
+			vars = append(vars, v)
+		}
+	})
+
+	w.Header().Set("Vary", "Accept")
+	offers := make([]string, len(envFormats))
+	for i, f := range envFormats {
+		offers[i] = f.mediaType
+	}
+	i := negotiate(r.Header.Get("Accept"), offers)
+	if i < 0 {
+		http.Error(w, "not acceptable, use one of: "+strings.Join(offers, ", "), http.StatusNotAcceptable)
+		return
+	}
+	format := envFormats[i].format
+	_, short := q["short"]
+	fingerprint := vs.Fingerprint()
+	w.Header().Set("X-Env-Fingerprint", fingerprint)
 
-	vs := r.URL.Query()
-	if _, ok := vs["short"]; ok {
-		shortHandler(w)
+	var buf bytes.Buffer
+	switch {
+	case format == yamlType && short:
+		err = shortYAML(&buf, vars)
+	case format == yamlType:
+		err = detailYAML(&buf, vars, fingerprint)
+	case format == dotenvType:
+		err = writeDotenv(&buf, vars)
+	case short:
+		err = shortHandler(&buf, vars)
+	default:
+		err = detailHandler(&buf, vars, fingerprint)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	detailHandler(w)
+	serveContent(w, r, mime.FormatMediaType(format, map[string]string{"charset": "utf-8"}), buf.Bytes())
 }
 
-func shortHandler(w io.Writer) {
-	fmt.Fprintf(w, "{\n")
-	first := true
-	env.Visit(func(v *env.Var) {
-		if !first {
-			fmt.Fprintf(w, ",\n")
+// serveMetrics responds with the metrics for vs and o (which may be nil).
+func serveMetrics(w http.ResponseWriter, r *http.Request, vs *env.VarSet, o *Overrides) {
+	var buf bytes.Buffer
+	err := WriteMetrics(&buf, vs, o)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", metricsContentType)
+	if r.Method != http.MethodHead {
+		w.Write(buf.Bytes())
+	}
+}
+
+// varFilter returns a function reporting whether a variable's name has the
+// given prefix and matches the glob pattern.  Empty arguments match all
+// names.
+func varFilter(prefix, pattern string) (func(*env.Var) bool, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid match %q: %v", pattern, err)
+	}
+	return func(v *env.Var) bool {
+		if !strings.HasPrefix(v.Name, prefix) {
+			return false
 		}
-		first = false
-		fmt.Fprintf(w, "    %q: %q", v.Name, v.Value.String())
-	})
-	fmt.Fprintf(w, "\n}\n")
-}
-
-func detailHandler(w io.Writer) {
-	fmt.Fprintf(w, "{\n    %q: [\n", "env")
-	first := true
-	env.Visit(func(v *env.Var) {
-		if !first {
-			fmt.Fprintf(w, ",\n")
-		}
-		first = false
-		fmt.Fprintf(w, "        {\n")
-		fmt.Fprintf(w, "            %q: %q,\n", "name", v.Name)
-		fmt.Fprintf(w, "            %q: %q,\n", "usage", v.Usage)
-		fmt.Fprintf(w, "            %q: %q\n", "value", v.Value.String())
-		fmt.Fprintf(w, "        }")
-	})
-	fmt.Fprintf(w, "\n    ]\n}\n")
+		if pattern == "" {
+			return true
+		}
+		ok, _ := path.Match(pattern, v.Name)
+		return ok
+	}, nil
 }
 
-func init() {
-	http.HandleFunc("/debug/env", envHandler)
+// negotiate returns the index of the offered media type most preferred by
+// the Accept header, or -1 if none are acceptable.  The preference of an
+// offer is the quality of the most specific media range matching it; ties
+// are broken by specificity and then by the order of offers.  An empty
+// Accept header accepts the first offer.
+func negotiate(accept string, offers []string) int {
+	if strings.TrimSpace(accept) == "" {
+		return 0
+	}
+
+	type mediaRange struct {
+		typ, sub string
+		q        float64
+	}
+	var ranges []mediaRange
+	for _, a := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(a)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+		typ, sub, _ := strings.Cut(mt, "/")
+		ranges = append(ranges, mediaRange{typ, sub, q})
+	}
+
+	best, bestQ, bestSpec := -1, 0.0, -1
+	for i, o := range offers {
+		typ, sub, _ := strings.Cut(o, "/")
+		q, spec := 0.0, -1
+		for _, r := range ranges {
+			var s int
+			switch {
+			case r.typ == typ && r.sub == sub:
+				s = 2
+			case r.typ == typ && r.sub == "*":
+				s = 1
+			case r.typ == "*" && r.sub == "*":
+				s = 0
+			default:
+				continue
+			}
+			if s > spec {
+				q, spec = r.q, s
+			}
+		}
+		if q > bestQ || q == bestQ && q > 0 && spec > bestSpec {
+			best, bestQ, bestSpec = i, q, spec
+		}
+	}
+	return best
+}
+
+// serveContent writes body with the given content type, an ETag derived
+// from both, and "Cache-Control: no-cache".  If the request's If-None-Match
+// header matches the ETag, then only the headers are written with status
+// 304 Not Modified.
+func serveContent(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	h := sha256.New()
+	io.WriteString(h, contentType)
+	h.Write([]byte{0})
+	h.Write(body)
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+// etagMatch reports whether the If-None-Match header value matches etag,
+// using the weak comparison required for If-None-Match.
+func etagMatch(header, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func shortHandler(w io.Writer, vars []*env.Var) error {
+	m := jsonObject{}
+	for _, v := range vars {
+		m = append(m, field{v.Name, displayValue(v)})
+	}
+	return writeJSON(w, m)
+}
+
+func shortYAML(w io.Writer, vars []*env.Var) error {
+	m := yamlMap{}
+	for _, v := range vars {
+		m = append(m, field{v.Name, displayValue(v)})
+	}
+	return writeYAML(w, m)
+}
+
+type detailVar struct {
+	Name    yamlString `json:"name" yaml:"name"`
+	Usage   yamlString `json:"usage" yaml:"usage"`
+	Value   yamlString `json:"value" yaml:"value"`
+	Type    string     `json:"type" yaml:"type"`
+	Default yamlString `json:"default,omitempty" yaml:"default,omitempty"`
+	Source  string     `json:"source,omitempty" yaml:"source,omitempty"`
+	Set     bool       `json:"set" yaml:"set"`
+}
+
+// detailEnv is the detailed description of the variables.
+type detailEnv struct {
+	Fingerprint string      `json:"fingerprint" yaml:"fingerprint"` // of the whole VarSet
+	Env         []detailVar `json:"env" yaml:"env"`
+}
+
+func newDetailEnv(vars []*env.Var, fingerprint string) detailEnv {
+	d := detailEnv{Fingerprint: fingerprint, Env: []detailVar{}}
+	for _, v := range vars {
+		source, set := v.Source(), v.Source() != ""
+		if !set && v.Default != "" {
+			source = "default"
+		}
+		def := v.Default
+		if v.Secret && def != "" {
+			def = redacted
+		}
+		d.Env = append(d.Env, detailVar{
+			Name:    yamlString(v.Name),
+			Usage:   yamlString(v.Usage),
+			Value:   yamlString(displayValue(v)),
+			Type:    v.Type(),
+			Default: yamlString(def),
+			Source:  source,
+			Set:     set,
+		})
+	}
+	return d
+}
+
+func detailHandler(w io.Writer, vars []*env.Var, fingerprint string) error {
+	return writeJSON(w, newDetailEnv(vars, fingerprint))
+}
+
+func detailYAML(w io.Writer, vars []*env.Var, fingerprint string) error {
+	return writeYAML(w, newDetailEnv(vars, fingerprint))
+}
+
+func writeDotenv(w io.Writer, vars []*env.Var) error {
+	for i, v := range vars {
+		if i > 0 {
+			fmt.Fprintf(w, "\n")
+		}
+		writeEnvVar(w, v, displayValue(v))
+	}
+	return nil
+}
+
+// redacted is displayed in place of the values of secret variables.
+const redacted = "<redacted>"
+
+// displayValue returns the value of v, or redacted if v is secret.
+func displayValue(v *env.Var) string {
+	if v.Secret {
+		return redacted
+	}
+	return v.Value.String()
 }
@@ -0,0 +1,188 @@
+package envsvc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"code.sajari.com/env"
+)
+
+func newTestVarSet(t *testing.T) *env.VarSet {
+	t.Helper()
+	vs := env.NewVarSet("svc")
+	vs.String("NAME", "name", env.Aliases("OLD_NAME"))
+	vs.Int("WORKERS", "workers", env.Default("4"))
+	vs.String("API_KEY", "api key", env.Secret())
+	vs.Sub("db").String("HOST", "db host", env.Optional())
+	if err := vs.Parse(mapGetter{"OLD_NAME": "name", "SVC_API_KEY": "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	return vs
+}
+
+func serve(vs *env.VarSet, method, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	serveEnv(w, r, vs)
+	return w
+}
+
+func TestServeEnv(t *testing.T) {
+	vs := newTestVarSet(t)
+
+	w := serve(vs, "GET", "/debug/env", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, expected %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type = %q, expected JSON", got)
+	}
+	var d detailEnv
+	if err := json.Unmarshal(w.Body.Bytes(), &d); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.Bytes(), err)
+	}
+	want := []detailVar{
+		{Name: "SVC_NAME", Usage: "name", Value: "name", Type: "string", Source: "OLD_NAME", Set: true},
+		{Name: "SVC_WORKERS", Usage: "workers", Value: "4", Type: "int", Default: "4", Source: "default"},
+		{Name: "SVC_API_KEY", Usage: "api key", Value: redacted, Type: "string", Source: "SVC_API_KEY", Set: true},
+		{Name: "SVC_DB_HOST", Usage: "db host", Type: "string"},
+	}
+	if !reflect.DeepEqual(d.Env, want) {
+		t.Errorf("env = %+v, expected %+v", d.Env, want)
+	}
+	if fp := vs.Fingerprint(); d.Fingerprint != fp || w.Header().Get("X-Env-Fingerprint") != fp {
+		t.Errorf("fingerprint = %q, header %q, expected %q", d.Fingerprint, w.Header().Get("X-Env-Fingerprint"), fp)
+	}
+}
+
+func TestServeEnvSecretDefault(t *testing.T) {
+	vs := env.NewVarSet("svc")
+	vs.String("SIGNING_KEY", "signing key", env.Secret(), env.Default("dev-key"))
+	if err := vs.Parse(mapGetter{}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(vs, "GET", "/debug/env", nil)
+	if strings.Contains(w.Body.String(), "dev-key") {
+		t.Errorf("response %q includes the default of a secret variable", w.Body.Bytes())
+	}
+	var d detailEnv
+	if err := json.Unmarshal(w.Body.Bytes(), &d); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.Bytes(), err)
+	}
+	want := []detailVar{
+		{Name: "SVC_SIGNING_KEY", Usage: "signing key", Value: redacted, Type: "string", Default: redacted, Source: "default"},
+	}
+	if !reflect.DeepEqual(d.Env, want) {
+		t.Errorf("env = %+v, expected %+v", d.Env, want)
+	}
+}
+
+func TestServeEnvFilter(t *testing.T) {
+	vs := newTestVarSet(t)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"short", `{"SVC_NAME":"name","SVC_WORKERS":"4","SVC_API_KEY":"<redacted>","SVC_DB_HOST":""}`},
+		{"short&prefix=SVC_DB_", `{"SVC_DB_HOST":""}`},
+		{"short&match=*_NAME", `{"SVC_NAME":"name"}`},
+		{"short&prefix=SVC_&match=*_[AW]*", `{"SVC_WORKERS":"4","SVC_API_KEY":"<redacted>"}`},
+		{"short&prefix=NONE", `{}`},
+	}
+	for _, tt := range tests {
+		w := serve(vs, "GET", "/debug/env?"+tt.query, nil)
+		got := strings.Join(strings.Fields(w.Body.String()), "")
+		if w.Code != http.StatusOK || got != tt.want {
+			t.Errorf("GET ?%v = %d %v, expected 200 %v", tt.query, w.Code, got, tt.want)
+		}
+	}
+
+	if w := serve(vs, "GET", "/debug/env?match=[", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("GET ?match=[ = %d, expected %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestServeEnvAccept(t *testing.T) {
+	vs := newTestVarSet(t)
+
+	tests := []struct {
+		accept, contentType, body string
+	}{
+		{"", "application/json; charset=utf-8", `"SVC_NAME": "name"`},
+		{"*/*", "application/json; charset=utf-8", `"SVC_NAME": "name"`},
+		{"application/yaml", "application/yaml; charset=utf-8", "SVC_NAME: name\n"},
+		{"text/html, application/x-yaml;q=0.9, */*;q=0.1", "application/yaml; charset=utf-8", "SVC_NAME: name\n"},
+		{"application/yaml, */*", "application/yaml; charset=utf-8", "SVC_NAME: name\n"},
+		{"text/*", "text/plain; charset=utf-8", "# name\nexport SVC_NAME=\"name\"\n"},
+		{"*/*, application/json;q=0, application/yaml;q=0", "text/plain; charset=utf-8", "export SVC_API_KEY=\"<redacted>\""},
+	}
+	for _, tt := range tests {
+		w := serve(vs, "GET", "/debug/env?short", http.Header{"Accept": {tt.accept}})
+		if w.Code != http.StatusOK {
+			t.Errorf("Accept %q: status = %d, expected %d", tt.accept, w.Code, http.StatusOK)
+			continue
+		}
+		if got := w.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("Accept %q: Content-Type = %q, expected %q", tt.accept, got, tt.contentType)
+		}
+		if !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("Accept %q: body = %q, expected to contain %q", tt.accept, w.Body.String(), tt.body)
+		}
+	}
+
+	w := serve(vs, "GET", "/debug/env", http.Header{"Accept": {"text/html"}})
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("Accept text/html: status = %d, expected %d", w.Code, http.StatusNotAcceptable)
+	}
+}
+
+func TestServeEnvETag(t *testing.T) {
+	vs := newTestVarSet(t)
+
+	w := serve(vs, "GET", "/debug/env", nil)
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("ETag not set")
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Cache-Control = %q, expected %q", got, "no-cache")
+	}
+
+	w = serve(vs, "GET", "/debug/env", http.Header{"If-None-Match": {`"other", W/` + etag}})
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("If-None-Match: status = %d with %d byte body, expected %d and none", w.Code, w.Body.Len(), http.StatusNotModified)
+	}
+
+	w = serve(vs, "GET", "/debug/env", http.Header{"Accept": {"application/yaml"}, "If-None-Match": {etag}})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("If-None-Match with other format: status = %d, ETag %v, expected %d and a different ETag", w.Code, w.Header().Get("ETag"), http.StatusOK)
+	}
+
+	if err := vs.Parse(mapGetter{"SVC_NAME": "changed", "SVC_API_KEY": "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	w = serve(vs, "GET", "/debug/env", http.Header{"If-None-Match": {etag}})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("If-None-Match after change: status = %d, expected %d and a different ETag", w.Code, http.StatusOK)
+	}
+
+	w = serve(vs, "HEAD", "/debug/env", nil)
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("Content-Length") == "0" {
+		t.Errorf("HEAD: status = %d with %d byte body, expected %d and none", w.Code, w.Body.Len(), http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	Handler(VarSet(vs)).ServeHTTP(w, httptest.NewRequest("DELETE", "/debug/env", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("DELETE: status = %d, expected %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,92 @@
+package envsvc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"code.sajari.com/env"
+)
+
+// initEnv prompts for the value of each variable in vs, reading answers
+// from in and writing prompts to p, and writes the answers to w in the same
+// format as -env-dump.  Each answer is validated using Var.Validate, which
+// doesn't change the variable, and the prompt repeated until it is valid.
+// Answers are read as lines from in, so they are echoed by a terminal, even
+// for secret variables.
+//
+// Empty answers accept the value shown in brackets: the current value from g
+// if set, otherwise the default.  Optional variables without a value are
+// written commented out.
+func initEnv(w io.Writer, vs *env.VarSet, g env.Getter, in *bufio.Reader, p io.Writer) error {
+	var vars []*env.Var
+	vs.Visit(func(v *env.Var) {
+		vars = append(vars, v)
+	})
+
+	for i, v := range vars {
+		if i > 0 {
+			fmt.Fprintf(w, "\n")
+		}
+
+		fmt.Fprintf(p, "\n%v\n", v.Name)
+		if v.Usage != "" {
+			fmt.Fprintf(p, "  %v\n", v.Usage)
+		}
+		details := []string{"type: " + v.Type()}
+		if v.Default != "" {
+			details = append(details, "default: "+v.Default)
+		}
+		if v.Example != "" {
+			details = append(details, "example: "+v.Example)
+		}
+		if c := v.Constraints.String(); c != "" {
+			details = append(details, c)
+		}
+		if v.Optional {
+			details = append(details, "optional")
+		}
+		fmt.Fprintf(p, "  (%v)\n", strings.Join(details, ", "))
+
+		value, shown, ok := v.Default, v.Default, false
+		if z, set := v.Get(g); set {
+			value, shown = z, z
+			if v.Secret {
+				shown = redacted
+			}
+		}
+
+		for {
+			fmt.Fprintf(p, "%v [%v]: ", v.Name, shown)
+			line, err := in.ReadString('\n')
+			if err != nil && (err != io.EOF || line == "") {
+				if err == io.EOF {
+					return errors.New("unexpected end of input")
+				}
+				return err
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if line == "" {
+				line = value
+			}
+			if line == "" && !v.Required() {
+				break
+			}
+			if err := v.Validate(line); err != nil {
+				fmt.Fprintf(p, "  invalid value: %v\n", err)
+				continue
+			}
+			value, ok = line, true
+			break
+		}
+
+		if ok {
+			writeEnvVar(w, v, value)
+		} else {
+			fmt.Fprintf(w, "# %v\n# export %v=\"\"\n", v.Usage, v.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package envsvc
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"code.sajari.com/env"
+)
+
+func TestInitEnv(t *testing.T) {
+	vs := env.NewVarSet("svc")
+	listen := vs.BindAddr("LISTEN", "bind address")
+	vs.Int("WORKERS", "number of workers", env.Default("4"), env.Min(1))
+	vs.String("KEY", "api key", env.Secret())
+	vs.String("LEVEL", "log level", env.Optional(), env.OneOf("debug", "info"))
+
+	answers := strings.Join([]string{
+		"localhost", // invalid bind address
+		":8080",
+		"0", // less than minimum
+		"",  // accept default
+		"",  // accept current value
+		"",  // skip optional
+	}, "\n") + "\n"
+
+	var out, prompts bytes.Buffer
+	err := initEnv(&out, vs, env.Map{"SVC_KEY": "secret"}, bufio.NewReader(strings.NewReader(answers)), &prompts)
+	if err != nil {
+		t.Fatalf("initEnv() = %v", err)
+	}
+
+	want := `# bind address
+export SVC_LISTEN=":8080"
+
+# number of workers
+export SVC_WORKERS="4"
+
+# api key
+export SVC_KEY="secret"
+
+# log level
+# export SVC_LEVEL=""
+`
+	if got := out.String(); got != want {
+		t.Errorf("initEnv() wrote\n%v\nexpected\n%v", got, want)
+	}
+	if *listen != "" {
+		t.Errorf("initEnv() set SVC_LISTEN to %q, expected it to be unchanged", *listen)
+	}
+
+	p := prompts.String()
+	for _, s := range []string{
+		"SVC_WORKERS\n  number of workers\n  (type: int, default: 4, >= 1)\nSVC_WORKERS [4]: ",
+		"invalid value: address localhost: missing port in address",
+		"invalid value: 0 is less than minimum 1",
+		"SVC_KEY [<redacted>]: ",
+		`(type: string, one of "debug", "info", optional)`,
+	} {
+		if !strings.Contains(p, s) {
+			t.Errorf("initEnv() prompts\n%v\nexpected to contain %q", p, s)
+		}
+	}
+
+	// Running out of input is an error.
+	err = initEnv(&out, vs, env.Map{}, bufio.NewReader(strings.NewReader(":8080\n")), &prompts)
+	if err == nil {
+		t.Errorf("initEnv() = nil, expected error")
+	}
+}
@@ -0,0 +1,117 @@
+package envsvc
+
+import (
+	"encoding/json"
+	"io"
+
+	"code.sajari.com/env"
+)
+
+// jsonSchemaDialect is the JSON Schema version written by WriteJSONSchema.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Patterns matching the values accepted by strconv.ParseInt (base 10),
+// strconv.ParseFloat (excluding hexadecimal, infinity and NaN) and
+// strconv.ParseBool, for values given as strings.
+const (
+	intPattern   = `^[-+]?[0-9]+$`
+	floatPattern = `^[-+]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][-+]?[0-9]+)?$`
+	boolPattern  = `^(1|t|T|TRUE|true|True|0|f|F|FALSE|false|False)$`
+)
+
+// jsonSchema is the subset of JSON Schema used to describe a VarSet.
+type jsonSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        interface{}            `json:"type,omitempty"` // string, or []string for several
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Default     interface{}            `json:"default,omitempty"`
+	Examples    []interface{}          `json:"examples,omitempty"`
+	Enum        []interface{}          `json:"enum,omitempty"`
+	Pattern     string                 `json:"pattern,omitempty"`
+	Minimum     *float64               `json:"minimum,omitempty"`
+	Maximum     *float64               `json:"maximum,omitempty"`
+	Deprecated  bool                   `json:"deprecated,omitempty"`
+}
+
+// WriteJSONSchema writes a JSON Schema (draft 2020-12) describing the
+// variables in vs to w.  The schema describes an object with a property for
+// each variable, with its type, usage, default, example and constraints.
+//
+// Integer, float and bool variables have the JSON Schema types "integer",
+// "number" and "boolean" respectively, or "string" with a pattern matching
+// their values, as environment variables (and the values written by
+// -env-dump-json) are always strings.  All other variables are strings.
+// Minimum and maximum only apply to the number types, so string values are
+// checked against the pattern but not the bounds; enums include both forms.
+func WriteJSONSchema(w io.Writer, vs *env.VarSet) error {
+	s := &jsonSchema{
+		Schema:     jsonSchemaDialect,
+		Title:      vs.Name(),
+		Type:       "object",
+		Properties: make(map[string]*jsonSchema),
+	}
+	vs.Visit(func(v *env.Var) {
+		s.Properties[v.Name] = jsonSchemaVar(v)
+		if v.Required() {
+			s.Required = append(s.Required, v.Name)
+		}
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+func jsonSchemaVar(v *env.Var) *jsonSchema {
+	s := &jsonSchema{
+		Description: v.Usage,
+		Deprecated:  v.Deprecated != "",
+	}
+	switch v.Type() {
+	case "int", "int64":
+		s.Type = []string{"integer", "string"}
+		s.Pattern = intPattern
+	case "float32", "float64":
+		s.Type = []string{"number", "string"}
+		s.Pattern = floatPattern
+	case "bool":
+		s.Type = []string{"boolean", "string"}
+		s.Pattern = boolPattern
+	case "duration":
+		s.Type = "string"
+		s.Pattern = durationPattern
+	default:
+		s.Type = "string"
+	}
+
+	c := v.Constraints
+	for _, x := range c.Enum {
+		t := typedValue(v, x)
+		s.Enum = append(s.Enum, t)
+		if t != x {
+			// Also allow the value as a string.
+			s.Enum = append(s.Enum, x)
+		}
+	}
+	if c.Pattern != "" {
+		// JSON Schema has a single pattern per schema, so the constraint
+		// takes precedence over the type's pattern.
+		s.Pattern = c.Pattern
+	}
+	s.Minimum = c.Min
+	s.Maximum = c.Max
+	if v.Default != "" {
+		s.Default = typedValue(v, v.Default)
+	}
+	if v.Example != "" {
+		s.Examples = []interface{}{typedValue(v, v.Example)}
+	}
+	return s
+}
+
+func dumpJSONSchema(w io.Writer, vs *env.VarSet, _ env.Getter) error {
+	return WriteJSONSchema(w, vs)
+}
@@ -0,0 +1,146 @@
+package envsvc
+
+import (
+	"io"
+	"strings"
+
+	"code.sajari.com/env"
+)
+
+// K8sOptions configures the Kubernetes manifests written by WriteK8sEnv and
+// WriteK8sConfig.
+type K8sOptions struct {
+	// Name of the ConfigMap and Secret.  Defaults to the name of the VarSet,
+	// converted to a valid Kubernetes name.
+	Name string
+
+	// Namespace of the ConfigMap and Secret, omitted if empty.
+	Namespace string
+
+	// Getter provides the values of the variables.  Defaults to the process
+	// environment.
+	Getter env.Getter
+}
+
+func (o K8sOptions) withDefaults(vs *env.VarSet) K8sOptions {
+	if o.Name == "" {
+		o.Name = k8sName(vs.Name())
+	}
+	if o.Getter == nil {
+		o.Getter = env.OSEnv
+	}
+	return o
+}
+
+type k8sEnvVar struct {
+	Name      string        `yaml:"name"`
+	Value     *yamlString   `yaml:"value,omitempty"`
+	ValueFrom *k8sValueFrom `yaml:"valueFrom,omitempty"`
+}
+
+type k8sValueFrom struct {
+	SecretKeyRef k8sKeyRef `yaml:"secretKeyRef"`
+}
+
+type k8sKeyRef struct {
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+}
+
+type k8sEnvFromSource struct {
+	ConfigMapRef *k8sLocalRef `yaml:"configMapRef,omitempty"`
+	SecretRef    *k8sLocalRef `yaml:"secretRef,omitempty"`
+}
+
+type k8sLocalRef struct {
+	Name string `yaml:"name"`
+}
+
+type k8sObject struct {
+	APIVersion string                `yaml:"apiVersion"`
+	Kind       string                `yaml:"kind"`
+	Metadata   k8sMetadata           `yaml:"metadata"`
+	Type       string                `yaml:"type,omitempty"`
+	Data       map[string]yamlString `yaml:"data,omitempty"`
+	StringData map[string]yamlString `yaml:"stringData,omitempty"`
+}
+
+type k8sMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// WriteK8sEnv writes the env list of a Kubernetes container spec for the
+// variables in vs to w.  Secret variables reference keys in the Secret named
+// by o.Name, all other variables have their values inlined.
+func WriteK8sEnv(w io.Writer, vs *env.VarSet, o K8sOptions) error {
+	o = o.withDefaults(vs)
+
+	var vars []k8sEnvVar
+	vs.Visit(func(v *env.Var) {
+		x := k8sEnvVar{Name: v.Name}
+		if v.Secret {
+			x.ValueFrom = &k8sValueFrom{
+				SecretKeyRef: k8sKeyRef{Name: o.Name, Key: v.Name},
+			}
+		} else {
+			z := yamlString(get(v, o.Getter))
+			x.Value = &z
+		}
+		vars = append(vars, x)
+	})
+	return writeYAML(w, map[string][]k8sEnvVar{"env": vars})
+}
+
+// WriteK8sEnvFrom writes the envFrom list of a Kubernetes container spec to
+// w, referencing the ConfigMap and Secret written by WriteK8sConfig with the
+// same options.
+func WriteK8sEnvFrom(w io.Writer, vs *env.VarSet, o K8sOptions) error {
+	o = o.withDefaults(vs)
+	ref := &k8sLocalRef{Name: o.Name}
+	return writeYAML(w, map[string][]k8sEnvFromSource{"envFrom": {{ConfigMapRef: ref}, {SecretRef: ref}}})
+}
+
+// WriteK8sConfig writes a Kubernetes ConfigMap containing the values of the
+// non-secret variables in vs, and a Secret containing the secret variables,
+// both named o.Name.  They can be referenced from a container spec using the
+// envFrom list written by WriteK8sEnvFrom.
+func WriteK8sConfig(w io.Writer, vs *env.VarSet, o K8sOptions) error {
+	o = o.withDefaults(vs)
+
+	meta := k8sMetadata{Name: o.Name, Namespace: o.Namespace}
+	cm := k8sObject{APIVersion: "v1", Kind: "ConfigMap", Metadata: meta, Data: map[string]yamlString{}}
+	secret := k8sObject{APIVersion: "v1", Kind: "Secret", Metadata: meta, Type: "Opaque", StringData: map[string]yamlString{}}
+	vs.Visit(func(v *env.Var) {
+		z := yamlString(get(v, o.Getter))
+		if v.Secret {
+			secret.StringData[v.Name] = z
+		} else {
+			cm.Data[v.Name] = z
+		}
+	})
+	return writeYAML(w, cm, secret)
+}
+
+// k8sName converts name to a valid Kubernetes object name: lowercase
+// alphanumeric characters or '-', starting and ending with an alphanumeric
+// character.
+func k8sName(name string) string {
+	s := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9':
+			return r
+		case 'A' <= r && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, name)
+	s = strings.Trim(s, "-")
+	if len(s) > 253 {
+		s = strings.TrimRight(s[:253], "-")
+	}
+	if s == "" {
+		s = "env"
+	}
+	return s
+}
@@ -0,0 +1,113 @@
+package envsvc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"code.sajari.com/env"
+)
+
+// metricsContentType is the content type of the Prometheus text exposition
+// format written by WriteMetrics.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// parseErrors counts the errors from parsing each VarSet in
+// ParseWithOptions, and the values rejected by Overrides, keyed by
+// *env.VarSet.
+var parseErrors sync.Map
+
+// addParseErrors adds n to the count of errors from parsing vs.
+func addParseErrors(vs *env.VarSet, n int) {
+	c, _ := parseErrors.LoadOrStore(vs, new(atomic.Uint64))
+	c.(*atomic.Uint64).Add(uint64(n))
+}
+
+// reloadStats counts the changes to a variable made using Overrides.
+type reloadStats struct {
+	count uint64
+	last  time.Time
+}
+
+// WriteMetrics writes metrics describing the configuration in vs to w in
+// the Prometheus text exposition format:
+//
+//	service_config_info{name, value}            1 for each variable (the value label is omitted for secrets)
+//	config_fingerprint_info{fingerprint}        1, with the fingerprint of vs (see env.VarSet.Fingerprint)
+//	config_parse_errors_total                   errors from parsing vs, and values for it rejected by Overrides
+//	config_reloads_total{name}                  changes to each variable made using o
+//	config_last_reload_timestamp_seconds{name}  time of the last change to each variable made using o
+//
+// ParseWithOptions normally exits if vs has errors, so in a running service
+// config_parse_errors_total counts the values rejected by Overrides, unless
+// Options.Exit doesn't exit.  o may be nil, in which case the reload metrics
+// are omitted.  Comparing
+// service_config_info across the instances of a service can be used to
+// alert when they are running with different configuration.
+func WriteMetrics(w io.Writer, vs *env.VarSet, o *Overrides) error {
+	if o != nil {
+		// Don't read values while they are being overridden.
+		o.mu.RLock()
+		defer o.mu.RUnlock()
+	}
+	bw := bufio.NewWriter(w)
+
+	writeMetricHeader(bw, "service_config_info", "gauge", "Configuration of the service, with the value of each non-secret variable.")
+	vs.Visit(func(v *env.Var) {
+		if v.Secret {
+			fmt.Fprintf(bw, "service_config_info{name=%v} 1\n", metricLabel(v.Name))
+		} else {
+			fmt.Fprintf(bw, "service_config_info{name=%v,value=%v} 1\n", metricLabel(v.Name), metricLabel(v.Value.String()))
+		}
+	})
+
+	writeMetricHeader(bw, "config_fingerprint_info", "gauge", "Fingerprint of the configuration of the service.")
+	fmt.Fprintf(bw, "config_fingerprint_info{fingerprint=%v} 1\n", metricLabel(vs.Fingerprint()))
+
+	var errs uint64
+	if c, ok := parseErrors.Load(vs); ok {
+		errs = c.(*atomic.Uint64).Load()
+	}
+	writeMetricHeader(bw, "config_parse_errors_total", "counter", "Number of errors parsing the configuration, including values rejected at runtime.")
+	fmt.Fprintf(bw, "config_parse_errors_total %d\n", errs)
+
+	if o != nil {
+		stats := o.reloads
+		names := make([]string, 0, len(stats))
+		for name := range stats {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		writeMetricHeader(bw, "config_reloads_total", "counter", "Number of changes to reloadable variables at runtime.")
+		for _, name := range names {
+			fmt.Fprintf(bw, "config_reloads_total{name=%v} %d\n", metricLabel(name), stats[name].count)
+		}
+		writeMetricHeader(bw, "config_last_reload_timestamp_seconds", "gauge", "Time of the last change to reloadable variables at runtime.")
+		for _, name := range names {
+			t := float64(stats[name].last.UnixNano()) / 1e9
+			fmt.Fprintf(bw, "config_last_reload_timestamp_seconds{name=%v} %v\n", metricLabel(name), strconv.FormatFloat(t, 'f', 3, 64))
+		}
+	}
+
+	return bw.Flush()
+}
+
+func writeMetricHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", name, help, name, typ)
+}
+
+// metricLabelEscaper escapes label values in the text exposition format.
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricLabel returns x as a quoted label value.  Invalid UTF-8 is replaced,
+// as label values must be valid UTF-8.
+func metricLabel(x string) string {
+	return `"` + metricLabelEscaper.Replace(strings.ToValidUTF8(x, "\uFFFD")) + `"`
+}
@@ -0,0 +1,84 @@
+package envsvc
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"code.sajari.com/env"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	vs := env.NewVarSet("svc")
+	vs.String("NAME", "name")
+	vs.String("QUOTED", "quoted")
+	vs.String("KEY", "key", env.Secret())
+	if err := vs.Parse(mapGetter{"SVC_NAME": "a", "SVC_QUOTED": "\"x\\y\"\n\xff", "SVC_KEY": "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	addParseErrors(vs, 2)
+
+	var buf bytes.Buffer
+	if err := WriteMetrics(&buf, vs, nil); err != nil {
+		t.Fatalf("WriteMetrics() = %v", err)
+	}
+	want := `# HELP service_config_info Configuration of the service, with the value of each non-secret variable.
+# TYPE service_config_info gauge
+service_config_info{name="SVC_NAME",value="a"} 1
+service_config_info{name="SVC_QUOTED",value="\"x\\y\"\n` + "�" + `"} 1
+service_config_info{name="SVC_KEY"} 1
+# HELP config_fingerprint_info Fingerprint of the configuration of the service.
+# TYPE config_fingerprint_info gauge
+config_fingerprint_info{fingerprint="` + vs.Fingerprint() + `"} 1
+# HELP config_parse_errors_total Number of errors parsing the configuration, including values rejected at runtime.
+# TYPE config_parse_errors_total counter
+config_parse_errors_total 2
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteMetrics() wrote\n%v\nexpected\n%v", got, want)
+	}
+}
+
+func TestServeMetrics(t *testing.T) {
+	vs, _, _ := newOverrideVarSet(t)
+	var o Overrides
+	h := Handler(VarSet(vs), AllowOverrides(&o))
+	post(h, `{"name": "SVC_LIMIT", "value": "20"}`)
+	post(h, `{"name": "SVC_LIMIT", "value": "30"}`)
+	post(h, `{"name": "SVC_LEVEL", "value": "debug"}`)
+	post(h, `{"name": "SVC_LIMIT", "value": "forty"}`) // rejected
+
+	mux := http.NewServeMux()
+	Register(mux, VarSet(vs), AllowOverrides(&o))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/debug/env/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /debug/env/metrics = %d, expected %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Content-Type"); got != metricsContentType {
+		t.Errorf("Content-Type = %q, expected %q", got, metricsContentType)
+	}
+
+	body := w.Body.String()
+	for _, s := range []string{
+		`service_config_info{name="SVC_LIMIT",value="30"} 1`,
+		"config_parse_errors_total 1\n",
+		"config_reloads_total{name=\"SVC_LEVEL\"} 1\nconfig_reloads_total{name=\"SVC_LIMIT\"} 2\n",
+	} {
+		if !strings.Contains(body, s) {
+			t.Errorf("metrics\n%v\nexpected to contain %q", body, s)
+		}
+	}
+	if !regexp.MustCompile(`config_last_reload_timestamp_seconds\{name="SVC_LIMIT"\} \d+\.\d{3}\n`).MatchString(body) {
+		t.Errorf("metrics\n%v\nexpected reload timestamp for SVC_LIMIT", body)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/debug/env/metrics", strings.NewReader(`{"name": "SVC_LIMIT", "value": "1"}`)))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /debug/env/metrics = %d, expected %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,287 @@
+package envsvc
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"code.sajari.com/env"
+)
+
+// maxAudit is the number of entries kept in the audit trail of Overrides.
+const maxAudit = 1000
+
+// Overrides allows reloadable variables (see env.Reloadable) to be changed
+// at runtime by POST requests to the handler (see AllowOverrides), and keeps
+// an audit trail of the changes.
+//
+// The request body is a JSON object with the name of the variable, its new
+// value, and optionally a duration after which the change is reverted:
+//
+//	{"name": "MY_SERVICE_LOG_LEVEL", "value": "debug", "ttl": "15m"}
+//
+// The value is set using Var.Reload, so only variables defined using
+// env.NewLive can be changed, and the source of the variable (see
+// env.Var.Source) becomes "override".  The response is a JSON object with
+// the new and previous values, and the time the change will be reverted, if
+// any.  A further change before then replaces the pending revert, but still
+// reverts to the value (and source) from before the first change.
+//
+// The zero value is ready to use.  Overrides must not be copied after first
+// use.
+type Overrides struct {
+	// Identity returns who made a request, for the audit trail.  Defaults to
+	// the common name of the verified client certificate if any, otherwise
+	// the remote address.
+	Identity func(*http.Request) string
+
+	// Logger, if non-nil, is used to log each change.
+	Logger *slog.Logger
+
+	// afterFunc calls f after d, returning a function which stops it (as
+	// time.AfterFunc).  Replaced in tests.
+	afterFunc func(d time.Duration, f func()) (stop func() bool)
+
+	mu      sync.RWMutex
+	audit   []AuditEntry
+	reverts map[*env.Var]*revert
+	reloads map[string]reloadStats
+}
+
+// overrideSource is the source of variables changed using Overrides.
+const overrideSource = "override"
+
+// revert is a pending revert of a variable to its value before it was
+// overridden.
+type revert struct {
+	value  string
+	source string
+	at     time.Time
+	stop   func() bool
+}
+
+// AuditEntry records a change made using Overrides.
+type AuditEntry struct {
+	Time     time.Time
+	Who      string        // Identity of the requester, or "ttl" for reverts
+	Name     string        // name of the variable
+	Value    string        // new value, redacted if secret
+	Previous string        // previous value, redacted if secret
+	TTL      time.Duration // time until the change is reverted, if any
+	Revert   bool          // if true, the change is an automatic revert
+}
+
+// MarshalJSON encodes e with lower case field names, and TTL as a duration
+// string.
+func (e AuditEntry) MarshalJSON() ([]byte, error) {
+	var ttl string
+	if e.TTL > 0 {
+		ttl = e.TTL.String()
+	}
+	return json.Marshal(struct {
+		Time     time.Time `json:"time"`
+		Who      string    `json:"who"`
+		Name     string    `json:"name"`
+		Value    string    `json:"value"`
+		Previous string    `json:"previous"`
+		TTL      string    `json:"ttl,omitempty"`
+		Revert   bool      `json:"revert,omitempty"`
+	}{e.Time, e.Who, e.Name, e.Value, e.Previous, ttl, e.Revert})
+}
+
+// Audit returns the audit trail of changes, oldest first.  Only the most
+// recent 1000 changes are kept.
+func (o *Overrides) Audit() []AuditEntry {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return append([]AuditEntry(nil), o.audit...)
+}
+
+// AllowOverrides returns a HandlerOption which enables changing reloadable
+// variables using o.  GET requests with the query parameter "audit" return
+// the audit trail.
+//
+// As this allows changing the behaviour of the process, the handler should
+// also require authentication (see BearerToken, ClientCert and Authorize).
+func AllowOverrides(o *Overrides) HandlerOption {
+	return func(c *handlerConfig) {
+		c.overrides = o
+	}
+}
+
+type overrideRequest struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	TTL   string `json:"ttl"`
+}
+
+type overrideResponse struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Previous string     `json:"previous"`
+	Expires  *time.Time `json:"expires,omitempty"`
+}
+
+func (o *Overrides) serve(w http.ResponseWriter, r *http.Request, vs *env.VarSet) {
+	var req overrideRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+			http.Error(w, fmt.Sprintf("invalid ttl %q", req.TTL), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var v *env.Var
+	vs.Visit(func(x *env.Var) {
+		if x.Name == req.Name {
+			v = x
+		}
+	})
+	if v == nil {
+		http.Error(w, fmt.Sprintf("unknown env %v", req.Name), http.StatusNotFound)
+		return
+	}
+	if !v.Reloadable {
+		http.Error(w, fmt.Sprintf("env %v is not reloadable", v.Name), http.StatusForbidden)
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	prev, prevSource := v.Value.String(), v.Source()
+	if err := v.Reload(req.Value, overrideSource); err != nil {
+		// Counted as a parse error in the metrics (see WriteMetrics),
+		// as a running service has no others.
+		addParseErrors(vs, 1)
+		http.Error(w, fmt.Sprintf("could not set env %v: %v", v.Name, err), http.StatusBadRequest)
+		return
+	}
+
+	resp := overrideResponse{Name: v.Name, Value: displayValue(v), Previous: prev}
+	if v.Secret {
+		resp.Previous = redacted
+	}
+
+	original := &revert{value: prev, source: prevSource}
+	if rv := o.reverts[v]; rv != nil {
+		rv.stop()
+		original = rv
+		delete(o.reverts, v)
+	}
+	if ttl > 0 {
+		rv := &revert{value: original.value, source: original.source, at: time.Now().Add(ttl)}
+		afterFunc := o.afterFunc
+		if afterFunc == nil {
+			afterFunc = func(d time.Duration, f func()) func() bool { return time.AfterFunc(d, f).Stop }
+		}
+		rv.stop = afterFunc(ttl, func() { o.revert(v, rv) })
+		if o.reverts == nil {
+			o.reverts = make(map[*env.Var]*revert)
+		}
+		o.reverts[v] = rv
+		resp.Expires = &rv.at
+	}
+
+	o.record(AuditEntry{
+		Time:     time.Now(),
+		Who:      o.identity(r),
+		Name:     v.Name,
+		Value:    resp.Value,
+		Previous: resp.Previous,
+		TTL:      ttl,
+	})
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSONResponse(w, resp)
+}
+
+// revert sets v back to its value and source before it was overridden,
+// unless rv has since been replaced or cancelled.
+func (o *Overrides) revert(v *env.Var, rv *revert) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.reverts[v] != rv {
+		return
+	}
+	delete(o.reverts, v)
+
+	prev := displayValue(v)
+	if err := v.Reload(rv.value, rv.source); err != nil {
+		// The value was valid before the override, so this is unlikely.
+		if o.Logger != nil {
+			o.Logger.Error("could not revert env", "name", v.Name, "error", err)
+		}
+		return
+	}
+	o.record(AuditEntry{
+		Time:     time.Now(),
+		Who:      "ttl",
+		Name:     v.Name,
+		Value:    displayValue(v),
+		Previous: prev,
+		Revert:   true,
+	})
+}
+
+// record adds e to the audit trail and logs it.  o.mu must be held.
+func (o *Overrides) record(e AuditEntry) {
+	if len(o.audit) == maxAudit {
+		copy(o.audit, o.audit[1:])
+		o.audit = o.audit[:maxAudit-1]
+	}
+	o.audit = append(o.audit, e)
+
+	if o.reloads == nil {
+		o.reloads = make(map[string]reloadStats)
+	}
+	rs := o.reloads[e.Name]
+	rs.count++
+	rs.last = e.Time
+	o.reloads[e.Name] = rs
+
+	if o.Logger != nil {
+		args := []interface{}{"name", e.Name, "value", e.Value, "previous", e.Previous, "who", e.Who}
+		if e.TTL > 0 {
+			args = append(args, "ttl", e.TTL)
+		}
+		if e.Revert {
+			o.Logger.Info("env override reverted", args...)
+		} else {
+			o.Logger.Info("env overridden", args...)
+		}
+	}
+}
+
+func (o *Overrides) identity(r *http.Request) string {
+	if o.Identity != nil {
+		return o.Identity(r)
+	}
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		return r.TLS.VerifiedChains[0][0].Subject.CommonName
+	}
+	return r.RemoteAddr
+}
+
+func (o *Overrides) serveAudit(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSONResponse(w, struct {
+		Audit []AuditEntry `json:"audit"`
+	}{append([]AuditEntry{}, o.Audit()...)})
+}
+
+// writeJSONResponse writes x to w as JSON.
+func writeJSONResponse(w http.ResponseWriter, x interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writeJSON(w, x)
+}
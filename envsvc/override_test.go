@@ -0,0 +1,237 @@
+package envsvc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"code.sajari.com/env"
+)
+
+func newOverrideVarSet(t *testing.T) (*env.VarSet, *env.Live[string], *env.Live[int]) {
+	t.Helper()
+	vs := env.NewVarSet("svc")
+	level := env.NewLive(vs.String, "LEVEL", "log level", env.OneOf("debug", "info"))
+	limit := env.NewLive(vs.Int, "LIMIT", "rate limit")
+	env.NewLive(vs.String, "TOKEN", "token", env.Secret())
+	vs.String("NAME", "name")
+	if err := vs.Parse(mapGetter{"SVC_LEVEL": "info", "SVC_LIMIT": "10", "SVC_TOKEN": "old", "SVC_NAME": "name"}); err != nil {
+		t.Fatal(err)
+	}
+	return vs, level, limit
+}
+
+// source returns the source of the variable name in the response to GET
+// /debug/env from h.
+func source(t *testing.T, h http.Handler, name string) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/debug/env", nil))
+	var d struct {
+		Env []struct {
+			Name   string `json:"name"`
+			Source string `json:"source"`
+		} `json:"env"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range d.Env {
+		if v.Name == name {
+			return v.Source
+		}
+	}
+	t.Fatalf("GET /debug/env = %v, expected %v", w.Body, name)
+	return ""
+}
+
+func post(h http.Handler, body string) (*httptest.ResponseRecorder, overrideResponse) {
+	r := httptest.NewRequest("POST", "/debug/env", strings.NewReader(body))
+	r.RemoteAddr = "10.0.0.1:1234"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	var resp overrideResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestOverrides(t *testing.T) {
+	vs, level, limit := newOverrideVarSet(t)
+	var o Overrides
+	h := Handler(VarSet(vs), AllowOverrides(&o))
+
+	w, resp := post(h, `{"name": "SVC_LEVEL", "value": "debug"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST = %d %v, expected %d", w.Code, w.Body, http.StatusOK)
+	}
+	if resp.Value != "debug" || resp.Previous != "info" || resp.Expires != nil {
+		t.Errorf("POST returned %+v, expected debug, previous info and no expiry", resp)
+	}
+	if got := level.Load(); got != "debug" {
+		t.Errorf("LEVEL = %q, expected %q", got, "debug")
+	}
+	if got := source(t, h, "SVC_LEVEL"); got != "override" {
+		t.Errorf("source of LEVEL = %q, expected override", got)
+	}
+
+	w, resp = post(h, `{"name": "SVC_TOKEN", "value": "new"}`)
+	if w.Code != http.StatusOK || resp.Value != redacted || resp.Previous != redacted {
+		t.Errorf("POST secret = %d %+v, expected redacted values", w.Code, resp)
+	}
+
+	fp := vs.Fingerprint()
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"name": "SVC_LEVEL", "value": "trace"}`, http.StatusBadRequest},
+		{`{"name": "SVC_LIMIT", "value": "ten"}`, http.StatusBadRequest},
+		{`{"name": "SVC_LIMIT", "value": "20", "ttl": "soon"}`, http.StatusBadRequest},
+		{`{"name": "SVC_NAME", "value": "other"}`, http.StatusForbidden},
+		{`{"name": "SVC_MISSING", "value": "x"}`, http.StatusNotFound},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w, _ := post(h, tt.body); w.Code != tt.code {
+			t.Errorf("POST %v = %d, expected %d", tt.body, w.Code, tt.code)
+		}
+	}
+	if l, n := level.Load(), limit.Load(); l != "debug" || n != 10 {
+		t.Errorf("values changed to (%q, %d) by invalid requests, expected (debug, 10)", l, n)
+	}
+	// Rejected values don't change the variables' Values either, which
+	// are what the dumps show.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/debug/env?short", nil))
+	var short map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &short); err != nil || short["SVC_LEVEL"] != "debug" || short["SVC_LIMIT"] != "10" {
+		t.Errorf("GET ?short after invalid requests = %v (%v), expected debug and 10", w.Body, err)
+	}
+	if got := vs.Fingerprint(); got != fp {
+		t.Errorf("Fingerprint() = %q after invalid requests, expected %q", got, fp)
+	}
+
+	if w, resp := post(h, `{"name": "SVC_LIMIT", "value": "20"}`); w.Code != http.StatusOK || resp.Previous != "10" {
+		t.Errorf("POST after invalid requests = %d %+v, expected previous 10", w.Code, resp)
+	}
+
+	audit := o.Audit()
+	if len(audit) != 3 {
+		t.Fatalf("Audit() = %+v, expected 3 entries", audit)
+	}
+	if e := audit[0]; e.Who != "10.0.0.1:1234" || e.Name != "SVC_LEVEL" || e.Value != "debug" || e.Previous != "info" {
+		t.Errorf("Audit()[0] = %+v", e)
+	}
+	if e := audit[1]; e.Value != redacted || e.Previous != redacted {
+		t.Errorf("Audit()[1] = %+v, expected redacted values", e)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/debug/env?audit", nil))
+	if !strings.Contains(w.Body.String(), `"who": "10.0.0.1:1234"`) {
+		t.Errorf("GET ?audit = %v, expected audit trail", w.Body)
+	}
+
+	// Without AllowOverrides, POST is not allowed.
+	if w, _ := post(Handler(VarSet(vs)), `{"name": "SVC_LEVEL", "value": "info"}`); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST without overrides = %d, expected %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestOverridesTTL(t *testing.T) {
+	vs, _, limit := newOverrideVarSet(t)
+	o := Overrides{Identity: func(*http.Request) string { return "oncall" }}
+	// Timers are fired by the test.
+	var timers []func()
+	var stopped []bool
+	o.afterFunc = func(_ time.Duration, f func()) func() bool {
+		i := len(timers)
+		timers = append(timers, f)
+		stopped = append(stopped, false)
+		return func() bool {
+			stopped[i] = true
+			return true
+		}
+	}
+	h := Handler(VarSet(vs), AllowOverrides(&o))
+
+	w, resp := post(h, `{"name": "SVC_LIMIT", "value": "20", "ttl": "1h"}`)
+	if w.Code != http.StatusOK || resp.Expires == nil {
+		t.Fatalf("POST = %d %+v, expected expiry", w.Code, resp)
+	}
+	// A second change replaces the pending revert, but still reverts to the
+	// original value.
+	w, resp = post(h, `{"name": "SVC_LIMIT", "value": "30", "ttl": "1m"}`)
+	if w.Code != http.StatusOK || resp.Previous != "20" {
+		t.Fatalf("POST = %d %+v, expected previous 20", w.Code, resp)
+	}
+	if len(timers) != 2 || !stopped[0] {
+		t.Fatalf("started %d timers, stopped %v, expected the first stopped", len(timers), stopped)
+	}
+
+	// The replaced revert does nothing, even if its timer fires.
+	timers[0]()
+	if n := limit.Load(); n != 30 || len(o.Audit()) != 2 {
+		t.Fatalf("LIMIT = %d with audit %+v after replaced revert, expected 30 and no revert", n, o.Audit())
+	}
+	timers[1]()
+	if n := limit.Load(); n != 10 {
+		t.Errorf("LIMIT = %d, expected revert to 10", n)
+	}
+	if got := source(t, h, "SVC_LIMIT"); got != "SVC_LIMIT" {
+		t.Errorf("source of LIMIT = %q after revert, expected SVC_LIMIT", got)
+	}
+
+	audit := o.Audit()
+	if len(audit) != 3 {
+		t.Fatalf("Audit() = %+v, expected 3 entries", audit)
+	}
+	if e := audit[2]; !e.Revert || e.Who != "ttl" || e.Value != "10" || e.Previous != "30" {
+		t.Errorf("Audit()[2] = %+v, expected revert from 30 to 10", e)
+	}
+	if audit[0].Who != "oncall" || audit[0].TTL != time.Hour {
+		t.Errorf("Audit()[0] = %+v, expected oncall with 1h TTL", audit[0])
+	}
+
+	// Changing without a TTL cancels a pending revert.
+	post(h, `{"name": "SVC_LIMIT", "value": "40", "ttl": "1h"}`)
+	post(h, `{"name": "SVC_LIMIT", "value": "50"}`)
+	if len(timers) != 3 || !stopped[2] {
+		t.Fatalf("started %d timers, stopped %v, expected the third stopped", len(timers), stopped)
+	}
+	timers[2]()
+	audit = o.Audit()
+	if e := audit[len(audit)-1]; e.Revert || e.Value != "50" {
+		t.Errorf("last audit entry = %+v, expected change to 50 and no revert", e)
+	}
+	if n := limit.Load(); n != 50 {
+		t.Errorf("LIMIT = %d, expected 50", n)
+	}
+}
+
+func TestOverridesConcurrent(t *testing.T) {
+	vs, level, limit := newOverrideVarSet(t)
+	var o Overrides
+	h := Handler(VarSet(vs), AllowOverrides(&o))
+
+	// Run with -race: values are read without locks while they are changed.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if l := level.Load(); l != "debug" && l != "info" {
+				t.Errorf("LEVEL = %q", l)
+			}
+			limit.Load()
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		post(h, `{"name": "SVC_LEVEL", "value": "debug"}`)
+		post(h, `{"name": "SVC_LIMIT", "value": "20", "ttl": "1ms"}`)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/debug/env/metrics", nil))
+	}
+	<-done
+}
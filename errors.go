@@ -0,0 +1,69 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorKind is the kind of problem described by a VarError.
+type ErrorKind int
+
+// Kinds of VarError.
+const (
+	MissingVar          ErrorKind = iota + 1 // a required variable is not set
+	InvalidValue                             // the value could not be parsed
+	ConstraintViolation                      // the value doesn't satisfy a constraint, see Constraints
+	UnknownVar                               // a prefixed variable is not used by the set, see Unclaimed
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case MissingVar:
+		return "missing"
+	case InvalidValue:
+		return "invalid"
+	case ConstraintViolation:
+		return "constraint"
+	case UnknownVar:
+		return "unknown"
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+// VarError is an error from Parse concerning a single variable.  The Errors
+// returned by Parse contain a *VarError for each problem.
+type VarError struct {
+	Kind ErrorKind
+	Name string // name of the variable
+	Var  *Var   // variable, or nil for UnknownVar
+
+	Value      string // value of the variable, for InvalidValue and ConstraintViolation
+	Default    bool   // if true, Value is the default as the variable is not set
+	Suggestion string // for UnknownVar, see UnclaimedVar
+	Err        error  // error from setting the value, if any
+}
+
+func (e *VarError) Error() string {
+	switch {
+	case e.Kind == MissingVar:
+		return fmt.Sprintf("missing env %v", e.Name)
+	case e.Kind == UnknownVar:
+		return UnclaimedVar{Name: e.Name, Suggestion: e.Suggestion}.String()
+	case e.Default:
+		return fmt.Sprintf("could not set env %v to default: %v", e.Name, e.Err)
+	}
+	return fmt.Sprintf("could not set env %v: %v", e.Name, e.Err)
+}
+
+func (e *VarError) Unwrap() error {
+	return e.Err
+}
+
+// setError returns a *VarError for the error err from setting x to z.
+func setError(x *Var, z string, def bool, err error) *VarError {
+	kind := InvalidValue
+	if errors.As(err, &constraintError{}) {
+		kind = ConstraintViolation
+	}
+	return &VarError{Kind: kind, Name: x.Name, Var: x, Value: z, Default: def, Err: err}
+}
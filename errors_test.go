@@ -0,0 +1,60 @@
+package env_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"code.sajari.com/env"
+)
+
+func TestVarError(t *testing.T) {
+	vs := env.NewVarSet("app")
+	vs.String("NAME", "name test")
+	vs.Int("WORKERS", "workers test")
+	vs.String("LEVEL", "level test", env.OneOf("debug", "info"))
+	vs.Int("RETRIES", "retries test", env.Default("3"), env.Max(2))
+	vs.BindAddr("LISTEN", "listen test")
+	vs.SetStrict(true)
+
+	err := vs.Parse(env.Map{
+		"APP_WORKERS": "many",
+		"APP_LEVEL":   "trace",
+		"APP_LISTEN":  "localhost",
+		"APP_LEVLE":   "debug",
+	})
+	es, ok := err.(env.Errors)
+	if !ok {
+		t.Fatalf("vs.Parse() = %v, expected Errors", err)
+	}
+
+	type result struct {
+		Kind    env.ErrorKind
+		Name    string
+		Value   string
+		Default bool
+		Message string
+	}
+	var got []result
+	for _, e := range es {
+		var ve *env.VarError
+		if !errors.As(e, &ve) {
+			t.Fatalf("error %v is %T, expected *env.VarError", e, e)
+		}
+		if ve.Kind != env.UnknownVar && (ve.Var == nil || ve.Var.Name != ve.Name) {
+			t.Errorf("error %v has Var %v, expected %v", e, ve.Var, ve.Name)
+		}
+		got = append(got, result{ve.Kind, ve.Name, ve.Value, ve.Default, ve.Error()})
+	}
+	want := []result{
+		{env.MissingVar, "APP_NAME", "", false, "missing env APP_NAME"},
+		{env.InvalidValue, "APP_WORKERS", "many", false, `could not set env APP_WORKERS: invalid integer "many": invalid syntax`},
+		{env.ConstraintViolation, "APP_LEVEL", "trace", false, `could not set env APP_LEVEL: "trace" is not one of "debug", "info"`},
+		{env.ConstraintViolation, "APP_RETRIES", "3", true, "could not set env APP_RETRIES to default: 3 is greater than maximum 2"},
+		{env.InvalidValue, "APP_LISTEN", "localhost", false, "could not set env APP_LISTEN: address localhost: missing port in address"},
+		{env.UnknownVar, "APP_LEVLE", "", false, "unknown env APP_LEVLE (did you mean APP_LEVEL?)"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("vs.Parse() errors\n%+v\nexpected\n%+v", got, want)
+	}
+}
@@ -0,0 +1,232 @@
+package env
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Map is a Getter (and Lister) backed by a map of variable names to values.
+type Map map[string]string
+
+// Get implements Getter.
+func (m Map) Get(x string) (string, bool) {
+	v, ok := m[x]
+	return v, ok
+}
+
+// Names implements Lister.  The names are sorted.
+func (m Map) Names() []string {
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReadFile reads variables from the named file, which is either a JSON
+// object of names to values (as written by -env-dump-json), or a dotenv file
+// (see ReadDotenv).
+func ReadFile(name string) (Map, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Map
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		m, err = readJSON(b)
+	} else {
+		m, err = ReadDotenv(bytes.NewReader(b))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", name, err)
+	}
+	return m, nil
+}
+
+// readJSON reads a JSON object of names to values.  Non-string values
+// (numbers and bools) are converted to strings.
+func readJSON(b []byte) (Map, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+
+	m := make(Map, len(obj))
+	for k, v := range obj {
+		switch v := v.(type) {
+		case string:
+			m[k] = v
+		case json.Number, bool:
+			m[k] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("value of %v is not a string, number or bool", k)
+		}
+	}
+	return m, nil
+}
+
+// ReadDotenv reads variables from a dotenv file, which has a NAME=value
+// assignment on each line, optionally preceded by export.  Values can be
+// unquoted, in single quotes (read literally) or double quotes (with
+// backslash escapes, including those written by -env-dump).  Quoted values
+// may span multiple lines.  Blank lines and lines starting with # are
+// ignored.
+func ReadDotenv(r io.Reader) (Map, error) {
+	b, err := io.ReadAll(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(Map)
+	p := &dotenvParser{s: string(b), line: 1}
+	for {
+		name, value, ok, err := p.next()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", p.line, err)
+		}
+		if !ok {
+			return m, nil
+		}
+		m[name] = value
+	}
+}
+
+type dotenvParser struct {
+	s    string // remaining input
+	line int    // current line number
+}
+
+// next returns the next assignment, or ok == false at the end of the input.
+func (p *dotenvParser) next() (name, value string, ok bool, err error) {
+	for {
+		if p.s == "" {
+			return "", "", false, nil
+		}
+		line := p.s
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+		}
+		if t := strings.TrimSpace(line); t != "" && !strings.HasPrefix(t, "#") {
+			break
+		}
+		p.skipLine()
+	}
+
+	p.s = strings.TrimLeft(p.s, " \t")
+	if strings.HasPrefix(p.s, "export ") {
+		p.s = strings.TrimLeft(p.s[len("export "):], " \t")
+	}
+
+	i := strings.IndexAny(p.s, "=\n")
+	if i < 0 || p.s[i] != '=' {
+		return "", "", false, fmt.Errorf("expected NAME=value")
+	}
+	name = strings.TrimSpace(p.s[:i])
+	if name == "" {
+		return "", "", false, fmt.Errorf("empty name")
+	}
+	p.s = strings.TrimLeft(p.s[i+1:], " \t")
+
+	switch {
+	case strings.HasPrefix(p.s, `"`):
+		value, err = p.quoted('"')
+	case strings.HasPrefix(p.s, "'"):
+		value, err = p.quoted('\'')
+	default:
+		value = p.s
+		if i := strings.IndexByte(value, '\n'); i >= 0 {
+			value = value[:i]
+		}
+		p.skipLine()
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		value = strings.TrimSpace(value)
+	}
+	if err != nil {
+		return "", "", false, err
+	}
+	return name, value, true, nil
+}
+
+// quoted reads a value in quotes q, which must be followed by the end of
+// the line or a comment.
+func (p *dotenvParser) quoted(q byte) (string, error) {
+	i := 1
+	for ; i < len(p.s) && p.s[i] != q; i++ {
+		if p.s[i] == '\\' && q == '"' {
+			i++
+		}
+	}
+	if i >= len(p.s) {
+		return "", fmt.Errorf("missing closing quote %c", q)
+	}
+
+	raw := p.s[1:i]
+	p.line += strings.Count(raw, "\n")
+	p.s = p.s[i+1:]
+
+	rest := p.s
+	if j := strings.IndexByte(rest, '\n'); j >= 0 {
+		rest = rest[:j]
+	}
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected %q after closing quote", rest)
+	}
+	p.skipLine()
+
+	if q == '\'' {
+		return raw, nil
+	}
+	return unquoteDouble(raw), nil
+}
+
+// skipLine advances past the end of the current line.
+func (p *dotenvParser) skipLine() {
+	if i := strings.IndexByte(p.s, '\n'); i >= 0 {
+		p.s = p.s[i+1:]
+		p.line++
+		return
+	}
+	p.s = ""
+}
+
+// unquoteDouble interprets the backslash escapes in the contents of
+// a double-quoted value: Go escapes (as written by -env-dump) if they are
+// all valid, otherwise a backslash escapes the following character, with \n,
+// \r and \t also recognised.
+func unquoteDouble(s string) string {
+	if u, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return u
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			i++
+			switch c = s[i]; c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package env_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"code.sajari.com/env"
+)
+
+func TestReadDotenv(t *testing.T) {
+	in := `# comment
+export DUMPED="a \"quoted\"\x00 value\n"
+
+PLAIN=plain value # comment
+  SPACED = spaced  
+EMPTY=
+SINGLE='single $HOME \n' # comment
+SYSTEMD="cost \$5 \\ \` + "`" + ` done"
+MULTI="line 1
+line 2"
+HASH=a#b
+`
+	got, err := env.ReadDotenv(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("env.ReadDotenv() = %v", err)
+	}
+	want := env.Map{
+		"DUMPED":  "a \"quoted\"\x00 value\n",
+		"PLAIN":   "plain value",
+		"SPACED":  "spaced",
+		"EMPTY":   "",
+		"SINGLE":  `single $HOME \n`,
+		"SYSTEMD": "cost $5 \\ ` done",
+		"MULTI":   "line 1\nline 2",
+		"HASH":    "a#b",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("env.ReadDotenv() = %q, expected %q", got, want)
+	}
+}
+
+func TestReadDotenvErrors(t *testing.T) {
+	tests := []struct {
+		in, err string
+	}{
+		{"NAME", "line 1: expected NAME=value"},
+		{"A=1\n=2", "line 2: empty name"},
+		{"A=\"1\n\nB=2", "line 1: missing closing quote \""},
+		{"A='1' 2", "line 1: unexpected \"2\" after closing quote"},
+	}
+
+	for _, tt := range tests {
+		_, err := env.ReadDotenv(strings.NewReader(tt.in))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("env.ReadDotenv(%q) = %v, expected %v", tt.in, err, tt.err)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	json := filepath.Join(dir, "env.json")
+	if err := os.WriteFile(json, []byte(`{"A": "1", "B": 2, "C": true}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	dotenv := filepath.Join(dir, ".env")
+	if err := os.WriteFile(dotenv, []byte("A=1\nB=2\nC=true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	want := env.Map{"A": "1", "B": "2", "C": "true"}
+	for _, name := range []string{json, dotenv} {
+		got, err := env.ReadFile(name)
+		if err != nil {
+			t.Errorf("env.ReadFile(%q) = %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("env.ReadFile(%q) = %q, expected %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package env
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"sort"
+	"strconv"
+)
+
+// Fingerprint returns a hash of the names and current values of the
+// variables in the set, as a hex-encoded SHA-256 sum.  It doesn't depend on
+// the order the variables were defined in, so comparing the fingerprints of
+// instances of a program shows whether they are running with the same
+// configuration without revealing it.
+//
+// Fingerprints are published (see envsvc), and a hash of a low-entropy
+// secret could be reversed by trying likely values, so secret values are
+// only included as an HMAC keyed by the key set with SetFingerprintKey.
+// Without a key, only the length of secret values is included.
+func (v *VarSet) Fingerprint() string {
+	vars := make([]*Var, len(v.vars))
+	copy(vars, v.vars)
+	sort.SliceStable(vars, func(i, j int) bool {
+		return vars[i].Name < vars[j].Name
+	})
+
+	key := v.fingerprintKey()
+	h := sha256.New()
+	for _, x := range vars {
+		value := x.Value.String()
+		if x.Secret {
+			if key != nil {
+				mac := hmac.New(sha256.New, key)
+				mac.Write([]byte(value))
+				value = string(mac.Sum(nil))
+			} else {
+				value = strconv.Itoa(len(value))
+			}
+		}
+		writeField(h, x.Name)
+		writeField(h, value)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SetFingerprintKey sets the key used to include the values of secret
+// variables in Fingerprint, so that instances with different secrets have
+// different fingerprints.  The key should itself be a secret shared by the
+// instances being compared, such as the value of a secret variable.  Child
+// sets use the key of their parent unless they have their own.
+func (v *VarSet) SetFingerprintKey(key []byte) {
+	v.fpKey = key
+}
+
+func (v *VarSet) fingerprintKey() []byte {
+	for s := v; s != nil; s = s.parent {
+		if s.fpKey != nil {
+			return s.fpKey
+		}
+	}
+	return nil
+}
+
+// writeField writes the length of s followed by s to h, so that the
+// boundaries between fields are unambiguous.
+func writeField(h hash.Hash, s string) {
+	var n [binary.MaxVarintLen64]byte
+	h.Write(n[:binary.PutUvarint(n[:], uint64(len(s)))])
+	h.Write([]byte(s))
+}
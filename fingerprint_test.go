@@ -0,0 +1,79 @@
+package env_test
+
+import (
+	"strings"
+	"testing"
+
+	"code.sajari.com/env"
+)
+
+func TestFingerprint(t *testing.T) {
+	newVarSet := func(reverse bool, g testGetter) *env.VarSet {
+		vs := env.NewVarSet("app")
+		if reverse {
+			vs.String("KEY", "key test", env.Secret())
+			vs.String("NAME", "name test")
+		} else {
+			vs.String("NAME", "name test")
+			vs.String("KEY", "key test", env.Secret())
+		}
+		if err := vs.Parse(g); err != nil {
+			t.Fatal(err)
+		}
+		return vs
+	}
+
+	g := testGetter{"APP_NAME": "name", "APP_KEY": "secret"}
+	fp := newVarSet(false, g).Fingerprint()
+	if len(fp) != 64 || strings.Trim(fp, "0123456789abcdef") != "" {
+		t.Errorf("Fingerprint() = %q, expected hex SHA-256", fp)
+	}
+	if got := newVarSet(true, g).Fingerprint(); got != fp {
+		t.Errorf("Fingerprint() = %q with different definition order, expected %q", got, fp)
+	}
+
+	for _, g := range []testGetter{
+		{"APP_NAME": "other", "APP_KEY": "secret"},
+		{"APP_NAME": "name", "APP_KEY": "other"},
+		{"APP_NAME": "namesecret", "APP_KEY": ""},
+	} {
+		if got := newVarSet(false, g).Fingerprint(); got == fp {
+			t.Errorf("Fingerprint() = %q for %v, expected it to differ", got, g)
+		}
+	}
+}
+
+func TestFingerprintSecret(t *testing.T) {
+	fingerprint := func(key, secret string) string {
+		vs := env.NewVarSet("app")
+		vs.String("NAME", "name test")
+		vs.Sub("db").String("PASSWORD", "password test", env.Secret())
+		if key != "" {
+			vs.SetFingerprintKey([]byte(key))
+		}
+		if err := vs.Parse(testGetter{"APP_NAME": "name", "APP_DB_PASSWORD": secret}); err != nil {
+			t.Fatal(err)
+		}
+		return vs.Fingerprint()
+	}
+
+	// Without the key, guessing the secret doesn't reproduce the
+	// fingerprint.
+	fp := fingerprint("fleet key", "hunter2")
+	for _, key := range []string{"", "other key"} {
+		if got := fingerprint(key, "hunter2"); got == fp {
+			t.Errorf("Fingerprint() with key %q = %q, expected it to differ without the key", key, got)
+		}
+	}
+	if got := fingerprint("fleet key", "hunter3"); got == fp {
+		t.Errorf("Fingerprint() = %q for different secrets with the same key, expected it to differ", got)
+	}
+
+	// Without a key, only the length of the secret is included.
+	if a, b := fingerprint("", "hunter2"), fingerprint("", "letmein"); a != b {
+		t.Errorf("Fingerprint() without a key = %q and %q for secrets of the same length, expected them to be equal", a, b)
+	}
+	if a, b := fingerprint("", "hunter2"), fingerprint("", "password"); a == b {
+		t.Errorf("Fingerprint() without a key = %q for secrets of different lengths, expected them to differ", a)
+	}
+}
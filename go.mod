@@ -1,3 +1,5 @@
 module code.sajari.com/env
 
-go 1.15
+go 1.21
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,127 @@
+package env
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Live holds the value of a reloadable variable (see NewLive).  Unlike the
+// pointers returned by VarSet.Int and the like, Load is safe to call
+// concurrently with changes to the value made using Var.Reload.
+type Live[T any] struct {
+	v atomic.Pointer[T]
+}
+
+// Load returns the current value of the variable.
+func (l *Live[T]) Load() T {
+	if p := l.v.Load(); p != nil {
+		return *p
+	}
+	var zero T
+	return zero
+}
+
+// NewLive defines a reloadable variable using define, one of the VarSet
+// methods or package functions such as VarSet.Int, and returns the Live
+// holding its value:
+//
+//	level := env.NewLive(vs.String, "LOG_LEVEL", "log level", env.Default("info"))
+//
+// The variable is marked as Reloadable.  The pointer returned by define is
+// only accessed by the variable's Value, which serialises changes to it and
+// publishes each new value to the Live.
+func NewLive[T any](define func(name, usage string, opts ...VarOption) *T, name, usage string, opts ...VarOption) *Live[T] {
+	lv := &liveValue[T]{live: new(Live[T])}
+	opts = append(opts[:len(opts):len(opts)], func(x *Var) {
+		x.Reloadable = true
+		lv.Value = x.Value
+		x.Value = lv
+	})
+	p := define(name, usage, opts...)
+
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+	lv.p = p
+	lv.publish()
+	return lv.live
+}
+
+// liveValue wraps the Value of a variable defined by NewLive, which stores
+// its value in p.
+type liveValue[T any] struct {
+	mu   sync.Mutex
+	p    *T // guarded by mu
+	live *Live[T]
+
+	Value // guarded by mu
+}
+
+// Set changes the value to x, unless it is invalid: the Values of the
+// built-in types are changed even when Set fails, so x is validated first.
+func (v *liveValue[T]) Set(x string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if err := validate(v.Value, x); err != nil {
+		return err
+	}
+	if err := v.Value.Set(x); err != nil {
+		return err
+	}
+	v.publish()
+	return nil
+}
+
+func (v *liveValue[T]) String() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.Value.String()
+}
+
+func (v *liveValue[T]) Type() string {
+	if t, ok := v.Value.(Typed); ok {
+		return t.Type()
+	}
+	return "string"
+}
+
+// validate returns the error Set would return for x, see validate.
+func (v *liveValue[T]) validate(x string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return validate(v.Value, x)
+}
+
+// publish stores a copy of the value in the Live.  v.mu must be held.
+func (v *liveValue[T]) publish() {
+	if v.p != nil {
+		c := *v.p
+		v.live.v.Store(&c)
+	}
+}
+
+func (*liveValue[T]) reloadable() {}
+
+// reloadableValue is implemented by Values which are safe to change while
+// the program is running.
+type reloadableValue interface {
+	Value
+	reloadable()
+}
+
+// Reload changes the value of a reloadable variable while the program is
+// running, recording source as the name it was read from (see Source).  The
+// value is unchanged if z is invalid.
+//
+// Reload is safe to call concurrently with reads of the variable's Live
+// value, but not with other calls to Reload, or with Source or Parse.
+func (x *Var) Reload(z, source string) error {
+	if _, ok := x.Value.(reloadableValue); !ok || !x.Reloadable {
+		return fmt.Errorf("env %v is not reloadable", x.Name)
+	}
+	if err := x.Value.Set(z); err != nil {
+		return err
+	}
+	x.source = source
+	return nil
+}
@@ -0,0 +1,82 @@
+package env_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"code.sajari.com/env"
+)
+
+func TestLive(t *testing.T) {
+	vs := env.NewVarSet("app")
+	timeout := env.NewLive(vs.Duration, "TIMEOUT", "timeout test", env.Default("1s"))
+	level := env.NewLive(vs.String, "LEVEL", "level test", env.OneOf("debug", "info"))
+	if err := vs.Parse(testGetter{"APP_LEVEL": "info"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := timeout.Load(); got != time.Second {
+		t.Errorf("TIMEOUT = %v, expected 1s", got)
+	}
+	if got := level.Load(); got != "info" {
+		t.Errorf("LEVEL = %q, expected info", got)
+	}
+
+	vars := make(map[string]*env.Var)
+	vs.Visit(func(v *env.Var) { vars[v.Name] = v })
+	x := vars["APP_LEVEL"]
+	if !x.Reloadable || x.Type() != "string" || x.Source() != "APP_LEVEL" {
+		t.Errorf("APP_LEVEL is reloadable %v, type %q, source %q", x.Reloadable, x.Type(), x.Source())
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if l := level.Load(); l != "debug" && l != "info" {
+				t.Errorf("LEVEL = %q", l)
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if err := x.Reload("debug", "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	if got := level.Load(); got != "debug" || x.Source() != "test" || x.Value.String() != "debug" {
+		t.Errorf("LEVEL = %q with source %q after Reload, expected debug from test", got, x.Source())
+	}
+
+	if err := x.Reload("trace", "test"); err == nil {
+		t.Error("Reload(trace) = nil, expected error")
+	}
+	if err := vars["APP_TIMEOUT"].Reload("soon", "test"); err == nil {
+		t.Error("Reload(soon) = nil, expected error")
+	}
+	if got := vars["APP_TIMEOUT"].Value.String(); got != "1s" {
+		t.Errorf("TIMEOUT value = %q after invalid Reload, expected 1s", got)
+	}
+	if err := x.Validate("trace"); err == nil {
+		t.Error("Validate(trace) = nil, expected error")
+	}
+	if got := level.Load(); got != "debug" || x.Source() != "test" {
+		t.Errorf("LEVEL = %q with source %q after invalid values, expected unchanged", got, x.Source())
+	}
+
+	vs.String("NAME", "name test")
+	vs.Visit(func(v *env.Var) { vars[v.Name] = v })
+	if err := vars["APP_NAME"].Reload("x", "test"); err == nil {
+		t.Error("Reload of variable not defined by NewLive = nil, expected error")
+	}
+}
+
+func TestReloadableRequiresLive(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic defining reloadable variable without NewLive")
+		}
+	}()
+	env.NewVarSet("app").Int("LIMIT", "limit test", env.Reloadable())
+}
@@ -0,0 +1,166 @@
+package env
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VarOption configures a variable when it is defined.
+type VarOption func(*Var)
+
+// Aliases returns a VarOption which adds alternative names for a variable,
+// which are read (in order) if the variable itself is not set.  Alias names
+// are used as given; the prefix of the variable set is not applied.
+func Aliases(names ...string) VarOption {
+	return func(x *Var) {
+		for _, name := range names {
+			x.Aliases = append(x.Aliases, Alias{Name: name})
+		}
+	}
+}
+
+// DeprecatedAlias returns a VarOption which adds a deprecated alternative
+// name for a variable, typically its old name.  Parse logs a warning
+// (including reason) if the variable is only set using the alias.
+func DeprecatedAlias(name, reason string) VarOption {
+	return func(x *Var) {
+		x.Aliases = append(x.Aliases, Alias{Name: name, Deprecated: reason})
+	}
+}
+
+// Deprecated returns a VarOption which marks a variable as deprecated.
+// Parse logs a warning (including reason) if the variable is set.
+func Deprecated(reason string) VarOption {
+	return func(x *Var) {
+		x.Deprecated = reason
+	}
+}
+
+// Secret returns a VarOption which marks a variable as sensitive, such as a
+// password or API key.  Secret values are not displayed by envsvc, and are
+// stored in Secrets rather than ConfigMaps in generated Kubernetes manifests.
+func Secret() VarOption {
+	return func(x *Var) {
+		x.Secret = true
+	}
+}
+
+// Reloadable returns a VarOption which allows a variable to be changed while
+// the program is running (see Var.Reload), such as by the override endpoint
+// of envsvc.  Reloadable variables must be defined using NewLive, so that
+// the value can be read safely while it is being changed; VarSet.Var panics
+// otherwise.
+func Reloadable() VarOption {
+	return func(x *Var) {
+		x.Reloadable = true
+	}
+}
+
+// Optional returns a VarOption which allows a variable to be unset, in which
+// case Parse leaves its value unchanged.
+func Optional() VarOption {
+	return func(x *Var) {
+		x.Optional = true
+	}
+}
+
+// Default returns a VarOption which sets the value used by Parse if the
+// variable is not set.
+func Default(value string) VarOption {
+	return func(x *Var) {
+		x.Default = value
+	}
+}
+
+// Example returns a VarOption which sets an example value for a variable.
+// Examples are included in documentation and schemas, and used by -env-dump
+// in envsvc for variables which are not set.  Use VarSet.CheckExamples in
+// tests to check that they are valid.
+func Example(value string) VarOption {
+	return func(x *Var) {
+		x.Example = value
+	}
+}
+
+// Constraints describes the values accepted by a variable.  They are set
+// (and enforced) by the OneOf, Pattern, Min and Max options, and can be used
+// when generating documentation and schemas.
+type Constraints struct {
+	Enum    []string // allowed values
+	Pattern string   // regular expression (RE2 syntax) values must match
+	Min     *float64 // minimum numeric value
+	Max     *float64 // maximum numeric value
+}
+
+// OneOf returns a VarOption which restricts a variable to one of values.
+func OneOf(values ...string) VarOption {
+	return func(x *Var) {
+		x.Constraints.Enum = values
+		x.Value = checkedValue{fn: isOneOf(values), constraint: true, Value: x.Value}
+	}
+}
+
+// Pattern returns a VarOption which requires values of a variable to match
+// the regular expression expr.  Pattern panics if expr is not a valid
+// regular expression.
+func Pattern(expr string) VarOption {
+	re := regexp.MustCompile(expr)
+	return func(x *Var) {
+		x.Constraints.Pattern = expr
+		x.Value = checkedValue{fn: isMatch(re), constraint: true, Value: x.Value}
+	}
+}
+
+// Min returns a VarOption which sets the minimum value of a numeric variable.
+// Defining a variable of another type (see Typed) with Min panics.
+func Min(n float64) VarOption {
+	return func(x *Var) {
+		requireNumeric(x, "Min")
+		x.Constraints.Min = &n
+		x.Value = checkedValue{fn: isInRange(&n, nil), constraint: true, Value: x.Value}
+	}
+}
+
+// Max returns a VarOption which sets the maximum value of a numeric variable.
+// Defining a variable of another type (see Typed) with Max panics.
+func Max(n float64) VarOption {
+	return func(x *Var) {
+		requireNumeric(x, "Max")
+		x.Constraints.Max = &n
+		x.Value = checkedValue{fn: isInRange(nil, &n), constraint: true, Value: x.Value}
+	}
+}
+
+// requireNumeric panics if x has a type which isn't numeric, as the option
+// opt would have no effect.
+func requireNumeric(x *Var, opt string) {
+	t, ok := x.Value.(Typed)
+	if !ok {
+		return
+	}
+	switch t.Type() {
+	case "int", "int64", "float32", "float64":
+	default:
+		panic(fmt.Sprintf("env: %v is a %v, so can't use %v", x.Name, t.Type(), opt))
+	}
+}
+
+// String describes the constraints, e.g. `one of "a", "b"; >= 1`.
+func (c Constraints) String() string {
+	var cs []string
+	if len(c.Enum) > 0 {
+		cs = append(cs, "one of "+strings.Join(quoteAll(c.Enum), ", "))
+	}
+	if c.Pattern != "" {
+		cs = append(cs, "matches "+strconv.Quote(c.Pattern))
+	}
+	if c.Min != nil {
+		cs = append(cs, ">= "+strconv.FormatFloat(*c.Min, 'f', -1, 64))
+	}
+	if c.Max != nil {
+		cs = append(cs, "<= "+strconv.FormatFloat(*c.Max, 'f', -1, 64))
+	}
+	return strings.Join(cs, "; ")
+}
@@ -0,0 +1,605 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+
+	"code.sajari.com/env"
+)
+
+// envPath is the import path of the env package.
+const envPath = "code.sajari.com/env"
+
+// command is a main package and the variable sets defined by it and the
+// packages it imports.
+type command struct {
+	Name string
+	Pkg  *packages.Package
+	Sets []*env.VarSet // root sets with variables, env.CmdVar first
+}
+
+// warning is a call to env which could not be interpreted.
+type warning struct {
+	Pos token.Position
+	Msg string
+}
+
+func (w warning) String() string {
+	return fmt.Sprintf("%v: %v", w.Pos, w.Msg)
+}
+
+// extractCommands returns the commands for each main package in roots,
+// interpreting the calls to env in them and the packages they import.
+func extractCommands(fset *token.FileSet, roots []*packages.Package) ([]*command, []warning) {
+	var cmds []*command
+	var warnings []warning
+	for _, p := range roots {
+		if p.Name != "main" || len(p.Syntax) == 0 {
+			continue
+		}
+
+		x := newExtractor(fset, commandName(p.PkgPath))
+		order := importOrder(p)
+		for _, dep := range order {
+			x.bind(dep)
+		}
+		for _, dep := range order {
+			x.define(dep)
+		}
+
+		cmd := &command{Name: x.cmd.Name(), Pkg: p}
+		for _, vs := range append([]*env.VarSet{x.cmd}, x.sets...) {
+			n := 0
+			vs.Visit(func(*env.Var) { n++ })
+			if n > 0 {
+				cmd.Sets = append(cmd.Sets, vs)
+			}
+		}
+		cmds = append(cmds, cmd)
+		warnings = append(warnings, x.warnings...)
+	}
+	return cmds, dedupWarnings(warnings)
+}
+
+// commandName returns the name of the binary built from the package with
+// the given import path, as go build would.
+func commandName(pkgPath string) string {
+	name := path.Base(pkgPath)
+	if majorVersion.MatchString(name) && path.Dir(pkgPath) != "." {
+		name = path.Base(path.Dir(pkgPath))
+	}
+	return name
+}
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// importOrder returns p and the packages it imports (directly or
+// indirectly) which import env, with dependencies before the packages which
+// import them, as they are initialised.
+func importOrder(p *packages.Package) []*packages.Package {
+	var order []*packages.Package
+	seen := make(map[string]bool)
+	var visit func(p *packages.Package)
+	visit = func(p *packages.Package) {
+		if seen[p.PkgPath] || p.PkgPath == envPath {
+			return
+		}
+		seen[p.PkgPath] = true
+		paths := make([]string, 0, len(p.Imports))
+		for path := range p.Imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			visit(p.Imports[path])
+		}
+		if _, ok := p.Imports[envPath]; ok && len(p.Syntax) > 0 && p.TypesInfo != nil {
+			order = append(order, p)
+		}
+	}
+	visit(p)
+	return order
+}
+
+func dedupWarnings(ws []warning) []warning {
+	seen := make(map[string]bool)
+	out := ws[:0]
+	for _, w := range ws {
+		if s := w.String(); !seen[s] {
+			seen[s] = true
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// extractor interprets the calls to env in the packages of a command, to
+// recreate the variable sets it defines.
+type extractor struct {
+	fset *token.FileSet
+	info *types.Info // of the package being interpreted
+
+	cmd      *env.VarSet                   // env.CmdVar
+	sets     []*env.VarSet                 // sets created by env.NewVarSet
+	newSets  map[*ast.CallExpr]*env.VarSet // sets created by each call to env.NewVarSet
+	objs     map[interface{}]*env.VarSet   // sets assigned to variables, see objKey
+	warnings []warning
+}
+
+func newExtractor(fset *token.FileSet, name string) *extractor {
+	return &extractor{
+		fset:    fset,
+		cmd:     env.NewVarSet(name),
+		newSets: make(map[*ast.CallExpr]*env.VarSet),
+		objs:    make(map[interface{}]*env.VarSet),
+	}
+}
+
+func (x *extractor) warnf(pos token.Pos, format string, args ...interface{}) {
+	x.warnings = append(x.warnings, warning{x.fset.Position(pos), fmt.Sprintf(format, args...)})
+}
+
+// objKey returns the key of obj in extractor.objs.  Package-level variables
+// are keyed by name, as each package which refers to them may have a
+// different types.Object for them.
+func objKey(obj types.Object) interface{} {
+	if obj.Pkg() != nil && obj.Parent() == obj.Pkg().Scope() {
+		return obj.Pkg().Path() + "." + obj.Name()
+	}
+	return obj
+}
+
+// bind records the variables in p which are assigned variable sets.
+func (x *extractor) bind(p *packages.Package) {
+	x.info = p.TypesInfo
+
+	// Repeat until nothing changes, so that the order of declarations
+	// doesn't matter.
+	for changed := true; changed; {
+		changed = false
+		assign := func(lhs, rhs ast.Expr) {
+			obj := x.object(lhs)
+			if obj == nil {
+				return
+			}
+			k := objKey(obj)
+			if _, ok := x.objs[k]; ok {
+				return
+			}
+			if vs := x.evalSet(rhs); vs != nil {
+				x.objs[k] = vs
+				changed = true
+			}
+		}
+		for _, f := range p.Syntax {
+			ast.Inspect(f, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.AssignStmt:
+					if len(n.Lhs) == len(n.Rhs) {
+						for i := range n.Lhs {
+							assign(n.Lhs[i], n.Rhs[i])
+						}
+					}
+				case *ast.ValueSpec:
+					if len(n.Names) == len(n.Values) {
+						for i := range n.Names {
+							assign(n.Names[i], n.Values[i])
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+}
+
+// object returns the variable referred to by e, or nil.
+func (x *extractor) object(e ast.Expr) types.Object {
+	var id *ast.Ident
+	switch e := unparen(e).(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.SelectorExpr:
+		id = e.Sel
+	default:
+		return nil
+	}
+	obj := x.info.Defs[id]
+	if obj == nil {
+		obj = x.info.Uses[id]
+	}
+	if _, ok := obj.(*types.Var); !ok {
+		return nil
+	}
+	return obj
+}
+
+// envFunc returns the function or method in the env package referred to by
+// e, such as env.Int or vs.Int, or nil.
+func (x *extractor) envFunc(e ast.Expr) *types.Func {
+	var id *ast.Ident
+	switch e := unparen(e).(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.SelectorExpr:
+		id = e.Sel
+	default:
+		return nil
+	}
+	fn, _ := x.info.Uses[id].(*types.Func)
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != envPath {
+		return nil
+	}
+	return fn
+}
+
+// evalSet returns the variable set that e evaluates to, or nil if it isn't
+// known.
+func (x *extractor) evalSet(e ast.Expr) *env.VarSet {
+	e = unparen(e)
+	if call, ok := e.(*ast.CallExpr); ok {
+		return x.evalSetCall(call)
+	}
+
+	obj := x.object(e)
+	if obj == nil {
+		return nil
+	}
+	if obj.Pkg() != nil && obj.Pkg().Path() == envPath && obj.Name() == "CmdVar" {
+		return x.cmd
+	}
+	return x.objs[objKey(obj)]
+}
+
+func (x *extractor) evalSetCall(call *ast.CallExpr) *env.VarSet {
+	fn, recv := x.envCallee(call)
+	if fn == nil {
+		return nil
+	}
+	switch fn.Name() {
+	case "NewVarSet":
+		if vs, ok := x.newSets[call]; ok {
+			return vs
+		}
+		name, ok := x.constString(call.Args[0])
+		if !ok {
+			x.warnf(call.Pos(), "env.NewVarSet called with non-constant name")
+			return nil
+		}
+		vs := env.NewVarSet(name)
+		x.newSets[call] = vs
+		x.sets = append(x.sets, vs)
+		return vs
+
+	case "Sub":
+		parent := x.cmd
+		if recv != nil {
+			if parent = x.evalSet(recv); parent == nil {
+				return nil
+			}
+		}
+		name, ok := x.constString(call.Args[0])
+		if !ok {
+			x.warnf(call.Pos(), "Sub called with non-constant name")
+			return nil
+		}
+		return parent.Sub(name)
+
+	case "Parent":
+		if recv != nil {
+			if vs := x.evalSet(recv); vs != nil {
+				return vs.Parent()
+			}
+		}
+	}
+	return nil
+}
+
+// envCallee returns the function or method in the env package called by
+// call, and the receiver expression if it is a method.
+func (x *extractor) envCallee(call *ast.CallExpr) (*types.Func, ast.Expr) {
+	fn, _ := typeutil.Callee(x.info, call).(*types.Func)
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != envPath {
+		return nil, nil
+	}
+	if fn.Type().(*types.Signature).Recv() == nil {
+		return fn, nil
+	}
+	sel, ok := unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return nil, nil
+	}
+	return fn, sel.X
+}
+
+// varDefs are the names of the functions and methods which define
+// variables.
+var varDefs = map[string]bool{
+	"String": true, "StringRequired": true, "Int": true, "Int64": true, "Float32": true,
+	"Float64": true, "Bool": true, "Duration": true, "BindAddr": true, "DialAddr": true,
+	"URL": true, "Path": true, "Var": true,
+}
+
+// define interprets the calls in p which define variables or configure
+// variable sets.
+func (x *extractor) define(p *packages.Package) {
+	x.info = p.TypesInfo
+	for _, f := range p.Syntax {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			fn, recv := x.envCallee(call)
+			if fn == nil {
+				return true
+			}
+			if fn.Type().(*types.Signature).Recv() != nil {
+				if named, ok := derefType(fn.Type().(*types.Signature).Recv().Type()).(*types.Named); !ok || named.Obj().Name() != "VarSet" {
+					return true
+				}
+			}
+
+			switch {
+			case varDefs[fn.Name()]:
+				vs := x.cmd
+				if recv != nil {
+					if vs = x.evalSet(recv); vs == nil {
+						x.warnf(call.Pos(), "can't determine the variable set of %v", fn.Name())
+						return true
+					}
+				}
+				x.defineVar(vs, fn.Name(), call, call.Args, false)
+			case recv == nil && fn.Name() == "NewLive" && len(call.Args) > 0:
+				// The first argument is the function or method defining
+				// the variable, such as vs.String.
+				def := x.envFunc(call.Args[0])
+				if def == nil || !varDefs[def.Name()] || def.Name() == "Var" {
+					x.warnf(call.Pos(), "can't interpret NewLive definition")
+					return true
+				}
+				vs := x.cmd
+				if sel, ok := unparen(call.Args[0]).(*ast.SelectorExpr); ok && def.Type().(*types.Signature).Recv() != nil {
+					if vs = x.evalSet(sel.X); vs == nil {
+						x.warnf(call.Pos(), "can't determine the variable set of %v", def.Name())
+						return true
+					}
+				}
+				x.defineVar(vs, def.Name(), call, call.Args[1:], true)
+			case recv != nil && (fn.Name() == "SetPrefix" || fn.Name() == "SetSeparator" || fn.Name() == "AliasUnprefixed"):
+				if vs := x.evalSet(recv); vs != nil {
+					x.configureSet(vs, fn.Name(), call)
+				} else {
+					x.warnf(call.Pos(), "can't determine the variable set of %v", fn.Name())
+				}
+			}
+			return true
+		})
+	}
+}
+
+func derefType(t types.Type) types.Type {
+	if p, ok := t.(*types.Pointer); ok {
+		return p.Elem()
+	}
+	return t
+}
+
+// defineVar defines the variable defined by call to the function or method
+// name, with arguments args, in vs.  live is set for calls to env.NewLive.
+func (x *extractor) defineVar(vs *env.VarSet, name string, call *ast.CallExpr, args []ast.Expr, live bool) {
+	if name == "Var" {
+		args = args[1:]
+	}
+	varName, ok := x.constString(args[0])
+	if !ok {
+		x.warnf(call.Pos(), "%v called with non-constant name", name)
+		return
+	}
+	usage, ok := x.constString(args[1])
+	if !ok {
+		x.warnf(args[1].Pos(), "%v: non-constant usage", varName)
+	}
+
+	var opts []env.VarOption
+	for _, arg := range args[2:] {
+		if call.Ellipsis.IsValid() {
+			x.warnf(arg.Pos(), "%v: options passed as a slice are ignored", varName)
+			break
+		}
+		opt, ok := x.option(arg)
+		if !ok {
+			x.warnf(arg.Pos(), "%v: can't interpret option", varName)
+			continue
+		}
+		opts = append(opts, opt)
+	}
+
+	defer func() {
+		// Invalid options, such as Pattern with an invalid expression, panic.
+		if r := recover(); r != nil {
+			x.warnf(call.Pos(), "%v: %v", varName, r)
+		}
+	}()
+	switch name {
+	case "String":
+		defineWith(vs.String, live, varName, usage, opts)
+	case "StringRequired":
+		defineWith(vs.StringRequired, live, varName, usage, opts)
+	case "Int":
+		defineWith(vs.Int, live, varName, usage, opts)
+	case "Int64":
+		defineWith(vs.Int64, live, varName, usage, opts)
+	case "Float32":
+		defineWith(vs.Float32, live, varName, usage, opts)
+	case "Float64":
+		defineWith(vs.Float64, live, varName, usage, opts)
+	case "Bool":
+		defineWith(vs.Bool, live, varName, usage, opts)
+	case "Duration":
+		defineWith(vs.Duration, live, varName, usage, opts)
+	case "BindAddr":
+		defineWith(vs.BindAddr, live, varName, usage, opts)
+	case "DialAddr":
+		defineWith(vs.DialAddr, live, varName, usage, opts)
+	case "URL":
+		defineWith(vs.URL, live, varName, usage, opts)
+	case "Path":
+		defineWith(vs.Path, live, varName, usage, opts)
+	case "Var":
+		vs.Var(new(opaqueValue), varName, usage, opts...) //envlint:ignore the name is read from the source being checked
+	}
+}
+
+// defineWith defines a variable using def, through env.NewLive if live.
+func defineWith[T any](def func(name, usage string, opts ...env.VarOption) *T, live bool, name, usage string, opts []env.VarOption) {
+	if live {
+		env.NewLive(def, name, usage, opts...)
+		return
+	}
+	def(name, usage, opts...)
+}
+
+// opaqueValue stands in for Values passed to VarSet.Var, which can't be
+// interpreted statically.
+type opaqueValue string
+
+func (v *opaqueValue) String() string     { return string(*v) }
+func (v *opaqueValue) Set(x string) error { *v = opaqueValue(x); return nil }
+
+// option returns the VarOption created by e, which must be a call to one of
+// the option functions in env with constant arguments.
+func (x *extractor) option(e ast.Expr) (env.VarOption, bool) {
+	call, ok := unparen(e).(*ast.CallExpr)
+	if !ok || call.Ellipsis.IsValid() {
+		return nil, false
+	}
+	fn, recv := x.envCallee(call)
+	if fn == nil || recv != nil {
+		return nil, false
+	}
+
+	var ss []string
+	for _, arg := range call.Args {
+		s, ok := x.constString(arg)
+		if !ok {
+			break
+		}
+		ss = append(ss, s)
+	}
+	strings := func(n int) bool {
+		return len(ss) == len(call.Args) && (n < 0 || len(ss) == n)
+	}
+
+	switch fn.Name() {
+	case "Aliases":
+		if strings(-1) {
+			return env.Aliases(ss...), true
+		}
+	case "DeprecatedAlias":
+		if strings(2) {
+			return env.DeprecatedAlias(ss[0], ss[1]), true
+		}
+	case "Deprecated":
+		if strings(1) {
+			return env.Deprecated(ss[0]), true
+		}
+	case "Default":
+		if strings(1) {
+			return env.Default(ss[0]), true
+		}
+	case "Example":
+		if strings(1) {
+			return env.Example(ss[0]), true
+		}
+	case "OneOf":
+		if strings(-1) {
+			return env.OneOf(ss...), true
+		}
+	case "Pattern":
+		if strings(1) {
+			return env.Pattern(ss[0]), true
+		}
+	case "Secret":
+		return env.Secret(), true
+	case "Optional":
+		return env.Optional(), true
+	case "Reloadable":
+		return env.Reloadable(), true
+	case "Min", "Max":
+		if len(call.Args) != 1 {
+			break
+		}
+		n, ok := x.constFloat(call.Args[0])
+		if !ok {
+			break
+		}
+		if fn.Name() == "Min" {
+			return env.Min(n), true
+		}
+		return env.Max(n), true
+	}
+	return nil, false
+}
+
+// configureSet applies the call to the VarSet method name to vs.
+func (x *extractor) configureSet(vs *env.VarSet, name string, call *ast.CallExpr) {
+	var ss []string
+	for _, arg := range call.Args {
+		s, ok := x.constString(arg)
+		if !ok || call.Ellipsis.IsValid() {
+			x.warnf(arg.Pos(), "%v called with non-constant argument", name)
+			return
+		}
+		ss = append(ss, s)
+	}
+	switch name {
+	case "SetPrefix":
+		vs.SetPrefix(ss[0])
+	case "SetSeparator":
+		vs.SetSeparator(ss[0])
+	case "AliasUnprefixed":
+		vs.AliasUnprefixed(ss...)
+	}
+}
+
+func (x *extractor) constString(e ast.Expr) (string, bool) {
+	tv, ok := x.info.Types[e]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+func (x *extractor) constFloat(e ast.Expr) (float64, bool) {
+	tv, ok := x.info.Types[e]
+	if !ok || tv.Value == nil {
+		return 0, false
+	}
+	v := constant.ToFloat(tv.Value)
+	if v.Kind() != constant.Float {
+		return 0, false
+	}
+	n, _ := constant.Float64Val(v)
+	return n, true
+}
+
+// unparen returns e with any enclosing parentheses removed.
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
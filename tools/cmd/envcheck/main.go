@@ -0,0 +1,270 @@
+// Command envcheck finds the environment variables defined using the env
+// package by the commands in a Go module, without building or running them,
+// and writes their documentation or schema.
+//
+// Usage:
+//
+//	envcheck [flags] [packages]
+//
+// The packages (default ./...) are loaded and type checked, and for each
+// main package the calls to env in it and the packages it imports are
+// interpreted to recreate the variable sets it defines: env.String,
+// env.BindAddr, VarSet.Int and so on, env.NewVarSet, VarSet.Sub and the
+// set's prefix and separator, along with options with constant arguments
+// such as env.Default, env.Secret and env.OneOf.  Variables defined in
+// env.CmdVar are named using the name of the command, as they would be when
+// run without ENV_PREFIX (see env.CmdPrefixEnv) set.
+//
+// Calls which can't be interpreted, such as variables with non-constant
+// names, are reported on stderr and skipped.
+//
+// The flags are:
+//
+//	-format F  output format: markdown (default) or html (see env.VarSet.WriteDocs),
+//	           jsonschema (see envsvc.WriteJSONSchema), or json
+//	-o DIR     write a file for each variable set to DIR, named COMMAND[.SET].EXT,
+//	           rather than writing to stdout
+//	-tags T    comma-separated list of build tags to apply when loading packages
+//
+// The exit status is 1 if the packages can't be loaded or the output can't
+// be written, and 2 for invalid flags.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"code.sajari.com/env"
+	"code.sajari.com/env/envsvc"
+)
+
+// formats are the values of -format, and the file extensions used for
+// them with -o.
+var formats = map[string]string{
+	"markdown":   ".md",
+	"html":       ".html",
+	"jsonschema": ".schema.json",
+	"json":       ".json",
+}
+
+func main() {
+	fs := flag.NewFlagSet("envcheck", flag.ExitOnError)
+	format := fs.String("format", "markdown", "output `format`: markdown, html, jsonschema or json")
+	dir := fs.String("o", "", "write a file for each variable set to `dir`")
+	tags := fs.String("tags", "", "comma-separated list of build `tags`")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: envcheck [flags] [packages]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[1:])
+
+	if _, ok := formats[*format]; !ok {
+		fmt.Fprintf(os.Stderr, "envcheck: unknown format %q\n", *format)
+		os.Exit(2)
+	}
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	cmds, err := load(patterns, *tags, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "envcheck: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *dir != "" {
+		err = writeFiles(*dir, cmds, *format)
+	} else {
+		err = write(os.Stdout, cmds, *format)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "envcheck: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// load loads the packages matching patterns and returns the commands among
+// them.  Warnings about calls which couldn't be interpreted are written to
+// stderr.
+func load(patterns []string, tags string, stderr io.Writer) ([]*command, error) {
+	cfg := &packages.Config{
+		Fset: token.NewFileSet(),
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes |
+			packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps | packages.NeedModule,
+	}
+	if tags != "" {
+		cfg.BuildFlags = []string{"-tags=" + tags}
+	}
+	roots, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if packages.PrintErrors(roots) > 0 {
+		return nil, errors.New("errors loading packages")
+	}
+
+	cmds, warnings := extractCommands(cfg.Fset, roots)
+	for _, w := range warnings {
+		fmt.Fprintln(stderr, w)
+	}
+	if len(cmds) == 0 {
+		return nil, fmt.Errorf("no commands in %v", strings.Join(patterns, " "))
+	}
+	return cmds, nil
+}
+
+// write writes the variable sets of cmds to w in format.
+func write(w io.Writer, cmds []*command, format string) error {
+	if format == "json" {
+		return writeJSON(w, cmds)
+	}
+
+	var sets []*env.VarSet
+	for _, c := range cmds {
+		sets = append(sets, c.Sets...)
+	}
+	if format == "jsonschema" && len(sets) > 1 {
+		return fmt.Errorf("%d variable sets found, use -o to write a schema for each", len(sets))
+	}
+	for i, vs := range sets {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if err := writeSet(w, vs, format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSet writes vs to w in format, which must not be json.
+func writeSet(w io.Writer, vs *env.VarSet, format string) error {
+	if format == "jsonschema" {
+		return envsvc.WriteJSONSchema(w, vs)
+	}
+	if format == string(env.Markdown) {
+		fmt.Fprintf(w, "## %v\n\n", vs.Name())
+	} else {
+		fmt.Fprintf(w, "<h2>%v</h2>\n", vs.Name())
+	}
+	return vs.WriteDocs(w, env.DocsFormat(format))
+}
+
+// writeFiles writes a file to dir for each variable set in cmds.
+func writeFiles(dir string, cmds []*command, format string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, c := range cmds {
+		for _, vs := range c.Sets {
+			name := c.Name
+			if vs.Name() != c.Name {
+				name += "." + vs.Name()
+			}
+			var buf bytes.Buffer
+			var err error
+			if format == "json" {
+				err = writeJSON(&buf, []*command{{Name: c.Name, Pkg: c.Pkg, Sets: []*env.VarSet{vs}}})
+			} else {
+				err = writeSet(&buf, vs, format)
+			}
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(dir, name+formats[format]), buf.Bytes(), 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type jsonCommand struct {
+	Name    string    `json:"name"`
+	Package string    `json:"package"`
+	Sets    []jsonSet `json:"sets"`
+}
+
+type jsonSet struct {
+	Name string    `json:"name"`
+	Vars []jsonVar `json:"vars"`
+}
+
+type jsonVar struct {
+	Name        string           `json:"name"`
+	Usage       string           `json:"usage"`
+	Type        string           `json:"type"`
+	Default     string           `json:"default,omitempty"`
+	Example     string           `json:"example,omitempty"`
+	Required    bool             `json:"required"`
+	Secret      bool             `json:"secret,omitempty"`
+	Reloadable  bool             `json:"reloadable,omitempty"`
+	Deprecated  string           `json:"deprecated,omitempty"`
+	Aliases     []jsonAlias      `json:"aliases,omitempty"`
+	Constraints *jsonConstraints `json:"constraints,omitempty"`
+}
+
+type jsonAlias struct {
+	Name       string `json:"name"`
+	Deprecated string `json:"deprecated,omitempty"`
+}
+
+type jsonConstraints struct {
+	Enum    []string `json:"enum,omitempty"`
+	Pattern string   `json:"pattern,omitempty"`
+	Min     *float64 `json:"min,omitempty"`
+	Max     *float64 `json:"max,omitempty"`
+}
+
+// writeJSON writes cmds to w as a JSON array of commands, each with its
+// variable sets and their variables.
+func writeJSON(w io.Writer, cmds []*command) error {
+	out := []jsonCommand{}
+	for _, c := range cmds {
+		jc := jsonCommand{Name: c.Name, Package: c.Pkg.PkgPath, Sets: []jsonSet{}}
+		for _, vs := range c.Sets {
+			js := jsonSet{Name: vs.Name(), Vars: []jsonVar{}}
+			vs.Visit(func(v *env.Var) {
+				js.Vars = append(js.Vars, newJSONVar(v))
+			})
+			jc.Sets = append(jc.Sets, js)
+		}
+		out = append(out, jc)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func newJSONVar(v *env.Var) jsonVar {
+	jv := jsonVar{
+		Name:       v.Name,
+		Usage:      v.Usage,
+		Type:       v.Type(),
+		Default:    v.Default,
+		Example:    v.Example,
+		Required:   v.Required(),
+		Secret:     v.Secret,
+		Reloadable: v.Reloadable,
+		Deprecated: v.Deprecated,
+	}
+	for _, a := range v.Aliases {
+		jv.Aliases = append(jv.Aliases, jsonAlias{a.Name, a.Deprecated})
+	}
+	if c := v.Constraints; len(c.Enum) > 0 || c.Pattern != "" || c.Min != nil || c.Max != nil {
+		jv.Constraints = &jsonConstraints{c.Enum, c.Pattern, c.Min, c.Max}
+	}
+	return jv
+}
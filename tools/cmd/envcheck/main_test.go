@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadTestdata(t *testing.T, stderr *bytes.Buffer) []*command {
+	t.Helper()
+	cmds, err := load([]string{"./testdata/app", "./testdata/other/v2"}, "", stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cmds
+}
+
+func TestLoad(t *testing.T) {
+	var stderr bytes.Buffer
+	cmds := loadTestdata(t, &stderr)
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, cmds); err != nil {
+		t.Fatal(err)
+	}
+	var got []jsonCommand
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(c jsonCommand) map[string][]string {
+		m := make(map[string][]string)
+		for _, s := range c.Sets {
+			m[s.Name] = []string{}
+			for _, v := range s.Vars {
+				m[s.Name] = append(m[s.Name], v.Name)
+			}
+		}
+		return m
+	}
+	expected := []struct {
+		name string
+		sets map[string][]string
+	}{
+		{"app", map[string][]string{
+			"app": {"APP_PORT", "APP_LOG_LEVEL", "APP_CACHE_TTL"},
+			"db":  {"DATABASE_DSN", "DATABASE_MAX_CONNS"},
+		}},
+		{"other", map[string][]string{
+			"other": {"OTHER_DEBUG"},
+		}},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("got %d commands, expected %d:\n%s", len(got), len(expected), buf.Bytes())
+	}
+	for i, e := range expected {
+		if got[i].Name != e.name {
+			t.Errorf("command %d is %q, expected %q", i, got[i].Name, e.name)
+		}
+		if n := names(got[i]); !equalSets(n, e.sets) {
+			t.Errorf("command %q has variables %v, expected %v", e.name, n, e.sets)
+		}
+	}
+	if got[0].Sets[0].Name != "app" {
+		t.Errorf("first set of app is %q, expected the command's set", got[0].Sets[0].Name)
+	}
+
+	level := got[0].Sets[0].Vars[1]
+	if !level.Reloadable || !level.Required || len(level.Aliases) != 1 || level.Aliases[0].Name != "LEVEL" ||
+		level.Constraints == nil || strings.Join(level.Constraints.Enum, ",") != "debug,info" {
+		t.Errorf("APP_LOG_LEVEL = %+v", level)
+	}
+	conns := got[0].Sets[1].Vars[1]
+	if conns.Type != "int" || conns.Default != "10" || conns.Required ||
+		conns.Constraints == nil || *conns.Constraints.Min != 1 || *conns.Constraints.Max != 100 {
+		t.Errorf("DATABASE_MAX_CONNS = %+v", conns)
+	}
+	if dsn := got[0].Sets[1].Vars[0]; !dsn.Secret || dsn.Example != "postgres://localhost/app" {
+		t.Errorf("DATABASE_DSN = %+v", dsn)
+	}
+
+	if w := stderr.String(); !strings.Contains(w, "app/main.go:20:") || !strings.Contains(w, "non-constant name") {
+		t.Errorf("expected warning about non-constant name, got %q", w)
+	}
+}
+
+func equalSets(a, b map[string][]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, x := range a {
+		if strings.Join(x, ",") != strings.Join(b[k], ",") {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWrite(t *testing.T) {
+	cmds := loadTestdata(t, new(bytes.Buffer))
+
+	var buf bytes.Buffer
+	if err := write(&buf, cmds, "markdown"); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"## app\n", "## db\n", "## other\n", "| `APP_PORT` | bindaddr | `:8080` | no |"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("markdown doesn't contain %q:\n%s", s, buf.String())
+		}
+	}
+
+	if err := write(new(bytes.Buffer), cmds, "jsonschema"); err == nil {
+		t.Error("expected error writing several schemas to one file")
+	}
+
+	dir := t.TempDir()
+	if err := writeFiles(dir, cmds, "jsonschema"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"app.schema.json", "app.db.schema.json", "other.schema.json"} {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var schema struct {
+			Properties map[string]interface{} `json:"properties"`
+		}
+		if err := json.Unmarshal(b, &schema); err != nil || len(schema.Properties) == 0 {
+			t.Errorf("%v: invalid schema (%v):\n%s", name, err, b)
+		}
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	for path, expected := range map[string]string{
+		"example.com/cmd/server":    "server",
+		"example.com/cmd/server/v2": "server",
+		"v2":                        "v2",
+	} {
+		if got := commandName(path); got != expected {
+			t.Errorf("commandName(%q) = %q, expected %q", path, got, expected)
+		}
+	}
+}
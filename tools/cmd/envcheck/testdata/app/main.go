@@ -0,0 +1,25 @@
+// Command app defines variables in env.CmdVar and imports lib.
+package main
+
+import (
+	"os"
+
+	"code.sajari.com/env"
+	"code.sajari.com/env/tools/cmd/envcheck/testdata/lib"
+)
+
+const portName = "PORT"
+
+var (
+	addr  = env.BindAddr(portName, "address to listen on", env.Default(":8080"))
+	level = env.NewLive(env.String, "LOG_LEVEL", "log level", env.OneOf("debug", "info"), env.Aliases("LEVEL"))
+
+	cache = env.Sub("cache")
+	ttl   = cache.Duration("TTL", "cache entry lifetime", env.Optional())
+
+	dynamic = env.String(os.Getenv("NAME"), "not constant")
+)
+
+func main() {
+	_, _, _, _, _ = addr, level, ttl, dynamic, lib.DSN
+}
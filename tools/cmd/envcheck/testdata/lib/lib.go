@@ -0,0 +1,15 @@
+// Package lib defines variables in its own variable set.
+package lib
+
+import "code.sajari.com/env"
+
+var vs = env.NewVarSet("db")
+
+var (
+	DSN   = vs.String("DSN", "database connection string", env.Secret(), env.Example("postgres://localhost/app"))
+	Conns = vs.Int("MAX_CONNS", "maximum number of connections", env.Default("10"), env.Min(1), env.Max(100))
+)
+
+func init() {
+	vs.SetPrefix("DATABASE")
+}
@@ -0,0 +1,10 @@
+// Command other has a major version suffix in its import path.
+package main
+
+import "code.sajari.com/env"
+
+var debug = env.Bool("DEBUG", "enable debugging")
+
+func main() {
+	_ = debug
+}
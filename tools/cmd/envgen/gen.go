@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// generate returns the Go source of package pkg for s: the struct type
+// s.Type with a field for each variable, and a function Register<Type>
+// which defines the variables in a VarSet.  source is the name of the
+// schema file, for the generated code header.
+func generate(s *schema, pkg, source string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by envgen from %v. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&b, "package %v\n\n", pkg)
+
+	std := make(map[string]bool)
+	for _, v := range s.Vars {
+		switch v.Type {
+		case "duration":
+			std[`"time"`] = true
+		case "url":
+			std[`"net/url"`] = true
+		}
+	}
+	b.WriteString("import (\n")
+	for _, path := range []string{`"net/url"`, `"time"`} {
+		if std[path] {
+			fmt.Fprintf(&b, "%v\n", path)
+		}
+	}
+	if len(std) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("\"code.sajari.com/env\"\n)\n\n")
+
+	if s.Description != "" {
+		writeComment(&b, "", s.Description)
+	} else {
+		writeComment(&b, "", fmt.Sprintf("%v is the configuration read from the environment, see Register%v.", s.Type, s.Type))
+	}
+	fmt.Fprintf(&b, "type %v struct {\n", s.Type)
+	for i, v := range s.Vars {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if v.Usage != "" {
+			writeComment(&b, "\t", fmt.Sprintf("%v is read from %v: %v", v.Field, v.Name, sentence(v.Usage)))
+		}
+		if v.Deprecated != "" {
+			if v.Usage != "" {
+				writeComment(&b, "\t", "")
+			}
+			writeComment(&b, "\t", "Deprecated: "+sentence(v.Deprecated))
+		}
+		typ := "*" + varTypes[v.Type].goType
+		if v.Reloadable {
+			typ = "*env.Live[" + varTypes[v.Type].goType + "]"
+		}
+		fmt.Fprintf(&b, "\t%v %v\n", v.Field, typ)
+	}
+	b.WriteString("}\n\n")
+
+	writeComment(&b, "", fmt.Sprintf("Register%v defines the variables of %v in vs (or env.CmdVar if vs is nil), "+
+		"and returns the %v which holds their values once vs is parsed.  The names are prefixed "+
+		"by vs as usual.", s.Type, s.Type, s.Type))
+	fmt.Fprintf(&b, "func Register%v(vs *env.VarSet) *%v {\n", s.Type, s.Type)
+	b.WriteString("\tif vs == nil {\n\t\tvs = env.CmdVar\n\t}\n")
+	fmt.Fprintf(&b, "\treturn &%v{\n", s.Type)
+	for _, v := range s.Vars {
+		args := []string{fmt.Sprintf("%q", v.Name), fmt.Sprintf("%q", v.Usage)}
+		opts, _ := v.options()
+		args = append(args, opts...)
+		if v.Reloadable {
+			args = append([]string{"vs." + varTypes[v.Type].method}, args...)
+			fmt.Fprintf(&b, "\t\t%v: env.NewLive(%v),\n", v.Field, strings.Join(args, ", "))
+			continue
+		}
+		fmt.Fprintf(&b, "\t\t%v: vs.%v(%v),\n", v.Field, varTypes[v.Type].method, strings.Join(args, ", "))
+	}
+	b.WriteString("\t}\n}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+// writeComment writes text as a line comment with the given indent,
+// wrapped at about 76 columns.
+func writeComment(b *bytes.Buffer, indent, text string) {
+	if text == "" {
+		fmt.Fprintf(b, "%v//\n", indent)
+		return
+	}
+	line := ""
+	for _, w := range strings.Fields(text) {
+		if line != "" && len(indent)*4+len(line)+len(w) > 73 {
+			fmt.Fprintf(b, "%v// %v\n", indent, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += w
+	}
+	fmt.Fprintf(b, "%v// %v\n", indent, line)
+}
+
+// sentence returns s ending with a full stop.
+func sentence(s string) string {
+	if strings.HasSuffix(s, ".") {
+		return s
+	}
+	return s + "."
+}
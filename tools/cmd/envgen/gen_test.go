@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	s, err := readSchema("testdata/config/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generate(s, "config", "config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile("testdata/config/config_env.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("generate() =\n%s\nexpected\n%s", got, expected)
+	}
+
+	// Type check the generated code against the env package.
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "testdata/config/config_env.go", got, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("config", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("generated code doesn't compile: %v", err)
+	}
+}
+
+func TestReadSchemaCUE(t *testing.T) {
+	if _, err := exec.LookPath("cue"); err != nil {
+		t.Skip("cue command not found")
+	}
+	name := filepath.Join(t.TempDir(), "schema.cue")
+	if err := os.WriteFile(name, []byte(`vars: [{name: "PORT", type: "int", default: "80"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := readSchema(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Vars) != 1 || s.Vars[0].Name != "PORT" || s.Vars[0].Type != "int" || s.Vars[0].Field != "Port" {
+		t.Errorf("readSchema() = %+v, expected PORT", s)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "schema.json")
+	if err := os.WriteFile(schema, []byte(`{"vars": [{"name": "PORT", "type": "int", "usage": "port", "default": "80"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOPACKAGE", "server")
+	docs := filepath.Join(dir, "ENV.html")
+	if err := run(schema, "", "", docs, "html"); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := os.ReadFile(filepath.Join(dir, "schema_env.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"package server\n", "type Config struct", "Port: vs.Int(\"PORT\", \"port\", env.Default(\"80\"))"} {
+		if !bytes.Contains(src, []byte(s)) {
+			t.Errorf("generated code doesn't contain %q:\n%s", s, src)
+		}
+	}
+	if b, err := os.ReadFile(docs); err != nil || !bytes.Contains(b, []byte("<code>PORT</code>")) {
+		t.Errorf("docs = %q (%v), expected a table containing PORT", b, err)
+	}
+}
+
+func TestSchemaErrors(t *testing.T) {
+	tests := []struct {
+		schema, err string
+	}{
+		{`vars: []`, "no vars"},
+		{`{"vars": [{"name": "port"}]}`, `invalid env name "port"`},
+		{`{"vars": [{"name": "PORT", "type": "integer"}]}`, `env PORT: unknown type "integer"`},
+		{`{"vars": [{"name": "PORT", "field": "port"}]}`, `env PORT: invalid field name "port"`},
+		{`{"vars": [{"name": "PORT"}, {"name": "PORT"}]}`, "env PORT defined more than once"},
+		{`{"vars": [{"name": "A_B"}, {"name": "AB", "field": "AB"}]}`, "env AB: field AB used more than once"},
+		{`{"vars": [{"name": "PORT", "type": "int", "default": "eighty"}]}`, "invalid default for env PORT"},
+		{`{"vars": [{"name": "PORT", "type": "int", "example": "0", "min": 1}]}`, "invalid example for env PORT"},
+		{`{"vars": [{"name": "PORT", "pattern": "("}]}`, "env PORT: regexp"},
+		{`{"vars": [{"name": "TIMEOUT", "type": "duration", "min": 1}]}`, "env TIMEOUT: env: TIMEOUT is a duration, so can't use Min"},
+		{`{"vars": [{"name": "PORT", "unknown": true}]}`, "field unknown not found"},
+		{`{"type": "config", "vars": [{"name": "PORT"}]}`, `invalid type name "config"`},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		name := filepath.Join(dir, "schema.json")
+		if err := os.WriteFile(name, []byte(tt.schema), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := readSchema(name)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("readSchema(%v) = %v, expected error containing %q", tt.schema, err, tt.err)
+		}
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"PORT":       "Port",
+		"LOG_LEVEL":  "LogLevel",
+		"API_URL":    "APIURL",
+		"HTTP2_ADDR": "HTTP2Addr",
+		"V2_API":     "V2API",
+		"_PRIVATE":   "Private",
+	} {
+		if got := fieldName(name); got != expected {
+			t.Errorf("fieldName(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Command envgen generates Go code and documentation for variables described
+// by a declarative schema, so that the variables a service reads can be
+// shared with the configuration of its deployments.
+//
+// Usage:
+//
+//	envgen [flags] schema.yaml
+//
+// It is intended to be run by go generate:
+//
+//	//go:generate go run code.sajari.com/env/tools/cmd/envgen -docs ENV.md config.yaml
+//
+// The schema is a YAML or JSON file (or CUE, which is exported to JSON using
+// the cue command, so it must be on the PATH) describing the variables:
+//
+//	package: config        # Go package, default $GOPACKAGE
+//	type: Config           # name of the generated struct, default Config
+//	name: my-service       # name of the variable set, used for the prefix in docs
+//	description: Config is the configuration of my-service.
+//	vars:
+//	  - name: LISTEN
+//	    type: bindaddr       # string (default), int, int64, float32, float64, bool,
+//	                         # duration, bindaddr, dialaddr, url or path
+//	    usage: address to listen on
+//	    default: ":8080"
+//	  - name: LOG_LEVEL
+//	    field: Level         # name of the struct field, default LogLevel
+//	    usage: log level
+//	    oneOf: [debug, info, warn, error]
+//	    reloadable: true
+//
+// The other properties of variables are example, optional, secret,
+// deprecated, aliases, deprecatedAliases (a map of alias to reason),
+// pattern, min and max (for numeric types), which correspond to the
+// options in env.  Defaults and examples are checked against the type and
+// constraints of the variable.
+//
+// The generated code defines the struct, with a pointer field for each
+// variable (an *env.Live for reloadable variables), and a function Register<Type>(vs *env.VarSet) which defines the
+// variables in vs and returns the struct holding their values.
+//
+// The flags are:
+//
+//	-o FILE            write the code to FILE, default <schema>_env.go
+//	-package NAME      package of the generated code, overriding the schema
+//	-docs FILE         also write a reference table of the variables to FILE
+//	-docs-format F     format of -docs: markdown (default) or html
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.sajari.com/env"
+)
+
+func main() {
+	fs := flag.NewFlagSet("envgen", flag.ExitOnError)
+	out := fs.String("o", "", "write the generated code to `file`, default <schema>_env.go")
+	pkg := fs.String("package", "", "`name` of the package of the generated code")
+	docs := fs.String("docs", "", "write a reference table of the variables to `file`")
+	docsFormat := fs.String("docs-format", string(env.Markdown), "`format` of -docs: markdown or html")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: envgen [flags] schema\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[1:])
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err := run(fs.Arg(0), *out, *pkg, *docs, env.DocsFormat(*docsFormat)); err != nil {
+		fmt.Fprintf(os.Stderr, "envgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the code (and docs, if docs is not empty) for the schema in
+// the file name.
+func run(name, out, pkg, docs string, docsFormat env.DocsFormat) error {
+	s, err := readSchema(name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case pkg != "":
+	case s.Package != "":
+		pkg = s.Package
+	case os.Getenv("GOPACKAGE") != "":
+		// Set by go generate.
+		pkg = os.Getenv("GOPACKAGE")
+	default:
+		return fmt.Errorf("no package: use -package or set package in %v", name)
+	}
+	if out == "" {
+		out = strings.TrimSuffix(name, filepath.Ext(name)) + "_env.go"
+	}
+
+	src, err := generate(s, pkg, filepath.Base(name))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		return err
+	}
+
+	if docs != "" {
+		vs, err := s.varSet()
+		if err != nil {
+			return err
+		}
+		var b bytes.Buffer
+		if err := vs.WriteDocs(&b, docsFormat); err != nil {
+			return err
+		}
+		if err := os.WriteFile(docs, b.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
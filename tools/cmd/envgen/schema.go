@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"code.sajari.com/env"
+)
+
+// schema is a declarative description of a set of variables.
+type schema struct {
+	Package     string      `yaml:"package"`     // Go package of the generated code
+	Type        string      `yaml:"type"`        // name of the generated struct, default Config
+	Name        string      `yaml:"name"`        // name of the variable set, used as the prefix in docs
+	Description string      `yaml:"description"` // doc comment of the generated struct
+	Vars        []schemaVar `yaml:"vars"`
+}
+
+// schemaVar describes a variable.  The fields correspond to the options in
+// env.
+type schemaVar struct {
+	Name              string            `yaml:"name"`
+	Field             string            `yaml:"field"` // name of the struct field, derived from Name by default
+	Type              string            `yaml:"type"`  // see varTypes, default string
+	Usage             string            `yaml:"usage"`
+	Default           string            `yaml:"default"`
+	Example           string            `yaml:"example"`
+	Optional          bool              `yaml:"optional"`
+	Secret            bool              `yaml:"secret"`
+	Reloadable        bool              `yaml:"reloadable"`
+	Deprecated        string            `yaml:"deprecated"`
+	Aliases           []string          `yaml:"aliases"`
+	DeprecatedAliases map[string]string `yaml:"deprecatedAliases"` // alias name to reason
+	OneOf             []string          `yaml:"oneOf"`
+	Pattern           string            `yaml:"pattern"`
+	Min               *float64          `yaml:"min"`
+	Max               *float64          `yaml:"max"`
+}
+
+// varType is a type of variable, defined by a VarSet method.
+type varType struct {
+	method string // VarSet method defining the variable
+	goType string // Go type of the value
+}
+
+// varTypes are the values of schemaVar.Type, which are the types described
+// by the variables' values (see env.Typed).
+var varTypes = map[string]varType{
+	"string":   {"String", "string"},
+	"int":      {"Int", "int"},
+	"int64":    {"Int64", "int64"},
+	"float32":  {"Float32", "float32"},
+	"float64":  {"Float64", "float64"},
+	"bool":     {"Bool", "bool"},
+	"duration": {"Duration", "time.Duration"},
+	"bindaddr": {"BindAddr", "string"},
+	"dialaddr": {"DialAddr", "string"},
+	"url":      {"URL", "url.URL"},
+	"path":     {"Path", "string"},
+}
+
+// validName matches portable environment variable names.
+var validName = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// readSchema reads the schema in the YAML or JSON file name.  CUE files are
+// exported to JSON using the cue command.
+func readSchema(name string) (*schema, error) {
+	var b []byte
+	var err error
+	if filepath.Ext(name) == ".cue" {
+		var stderr bytes.Buffer
+		cmd := exec.Command("cue", "export", "--out", "json", name)
+		cmd.Stderr = &stderr
+		if b, err = cmd.Output(); err != nil {
+			return nil, fmt.Errorf("cue export %v: %v: %s", name, err, bytes.TrimSpace(stderr.Bytes()))
+		}
+	} else if b, err = os.ReadFile(name); err != nil {
+		return nil, err
+	}
+
+	// JSON is a subset of YAML.
+	var s schema
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("%v: %v", name, err)
+	}
+	if err := s.check(); err != nil {
+		return nil, fmt.Errorf("%v: %v", name, err)
+	}
+	return &s, nil
+}
+
+// check fills in the defaults of s and reports the first problem with it.
+func (s *schema) check() error {
+	if s.Type == "" {
+		s.Type = "Config"
+	}
+	if !token.IsIdentifier(s.Type) || !token.IsExported(s.Type) {
+		return fmt.Errorf("invalid type name %q", s.Type)
+	}
+	if len(s.Vars) == 0 {
+		return fmt.Errorf("no vars")
+	}
+
+	names := make(map[string]bool)
+	fields := make(map[string]bool)
+	for i := range s.Vars {
+		v := &s.Vars[i]
+		if !validName.MatchString(v.Name) {
+			return fmt.Errorf("invalid env name %q: use upper case letters, digits and underscores", v.Name)
+		}
+		if v.Type == "" {
+			v.Type = "string"
+		}
+		if _, ok := varTypes[v.Type]; !ok {
+			return fmt.Errorf("env %v: unknown type %q", v.Name, v.Type)
+		}
+		if v.Field == "" {
+			v.Field = fieldName(v.Name)
+		}
+		if !token.IsIdentifier(v.Field) || !token.IsExported(v.Field) {
+			return fmt.Errorf("env %v: invalid field name %q", v.Name, v.Field)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("env %v defined more than once", v.Name)
+		}
+		if fields[v.Field] {
+			return fmt.Errorf("env %v: field %v used more than once", v.Name, v.Field)
+		}
+		names[v.Name], fields[v.Field] = true, true
+	}
+
+	// Check the options, and the default and example values, using the
+	// variables they define.
+	_, err := s.varSet()
+	return err
+}
+
+// initialisms are the words written in upper case in field names.
+var initialisms = map[string]bool{
+	"API": true, "DNS": true, "GRPC": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "SQL": true, "TCP": true, "TLS": true, "TTL": true,
+	"UDP": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// fieldName returns the Go field name for the variable name: LOG_LEVEL is
+// LogLevel, API_URL is APIURL and HTTP2_ADDR is HTTP2Addr.  Underscores are
+// dropped, so _PRIVATE is Private; a name starting with a digit is not a
+// valid identifier, and is reported by check.
+func fieldName(name string) string {
+	var b strings.Builder
+	for _, w := range strings.Split(name, "_") {
+		if w == "" {
+			continue
+		}
+		if initialisms[strings.TrimRight(w, "0123456789")] {
+			// Including initialisms followed by a version, such as HTTP2.
+			b.WriteString(w)
+			continue
+		}
+		r := []rune(strings.ToLower(w))
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
+
+// options returns the options of v, as Go source and as values.  Reloadable
+// variables are marked by env.NewLive, which defines them.
+func (v *schemaVar) options() ([]string, []env.VarOption) {
+	var src []string
+	var opts []env.VarOption
+	add := func(s string, opt env.VarOption) {
+		src = append(src, s)
+		opts = append(opts, opt)
+	}
+
+	if len(v.Aliases) > 0 {
+		add("env.Aliases("+quoteList(v.Aliases)+")", env.Aliases(v.Aliases...))
+	}
+	names := make([]string, 0, len(v.DeprecatedAliases))
+	for name := range v.DeprecatedAliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		reason := v.DeprecatedAliases[name]
+		add(fmt.Sprintf("env.DeprecatedAlias(%q, %q)", name, reason), env.DeprecatedAlias(name, reason))
+	}
+	if v.Deprecated != "" {
+		add(fmt.Sprintf("env.Deprecated(%q)", v.Deprecated), env.Deprecated(v.Deprecated))
+	}
+	if v.Default != "" {
+		add(fmt.Sprintf("env.Default(%q)", v.Default), env.Default(v.Default))
+	}
+	if v.Example != "" {
+		add(fmt.Sprintf("env.Example(%q)", v.Example), env.Example(v.Example))
+	}
+	if v.Optional {
+		add("env.Optional()", env.Optional())
+	}
+	if v.Secret {
+		add("env.Secret()", env.Secret())
+	}
+	if len(v.OneOf) > 0 {
+		add("env.OneOf("+quoteList(v.OneOf)+")", env.OneOf(v.OneOf...))
+	}
+	if v.Pattern != "" {
+		add(fmt.Sprintf("env.Pattern(%v)", quoteRaw(v.Pattern)), env.Pattern(v.Pattern))
+	}
+	if v.Min != nil {
+		add(fmt.Sprintf("env.Min(%v)", *v.Min), env.Min(*v.Min))
+	}
+	if v.Max != nil {
+		add(fmt.Sprintf("env.Max(%v)", *v.Max), env.Max(*v.Max))
+	}
+	return src, opts
+}
+
+// varSet returns a VarSet with the variables described by s, as defined by
+// the generated code.
+func (s *schema) varSet() (*env.VarSet, error) {
+	vs := env.NewVarSet(s.Name)
+	for i := range s.Vars {
+		v := &s.Vars[i]
+		if err := defineVar(vs, v); err != nil {
+			return nil, fmt.Errorf("env %v: %v", v.Name, err)
+		}
+	}
+
+	var verr error
+	vs.Visit(func(x *env.Var) {
+		if verr == nil && x.Default != "" {
+			if err := x.Validate(x.Default); err != nil {
+				verr = fmt.Errorf("invalid default for env %v: %v", x.Name, err)
+			}
+		}
+	})
+	if verr != nil {
+		return nil, verr
+	}
+	if err := vs.CheckExamples(); err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
+
+// defineVar defines v in vs.
+func defineVar(vs *env.VarSet, v *schemaVar) (err error) {
+	defer func() {
+		// Pattern panics if the expression is invalid.
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	_, opts := v.options()
+	switch v.Type {
+	case "string":
+		define(vs.String, v, opts)
+	case "int":
+		define(vs.Int, v, opts)
+	case "int64":
+		define(vs.Int64, v, opts)
+	case "float32":
+		define(vs.Float32, v, opts)
+	case "float64":
+		define(vs.Float64, v, opts)
+	case "bool":
+		define(vs.Bool, v, opts)
+	case "duration":
+		define(vs.Duration, v, opts)
+	case "bindaddr":
+		define(vs.BindAddr, v, opts)
+	case "dialaddr":
+		define(vs.DialAddr, v, opts)
+	case "url":
+		define(vs.URL, v, opts)
+	case "path":
+		define(vs.Path, v, opts)
+	}
+	return nil
+}
+
+// define defines v using the VarSet method def, or env.NewLive if v is
+// reloadable.
+func define[T any](def func(name, usage string, opts ...env.VarOption) *T, v *schemaVar, opts []env.VarOption) {
+	if v.Reloadable {
+		env.NewLive(def, v.Name, v.Usage, opts...)
+		return
+	}
+	def(v.Name, v.Usage, opts...)
+}
+
+func quoteList(ss []string) string {
+	q := make([]string, len(ss))
+	for i, s := range ss {
+		q[i] = fmt.Sprintf("%q", s)
+	}
+	return strings.Join(q, ", ")
+}
+
+// quoteRaw returns s as a raw string literal if possible, which is easier
+// to read for regular expressions.
+func quoteRaw(s string) string {
+	if strings.ContainsAny(s, "`\r") || !utf8Printable(s) {
+		return fmt.Sprintf("%q", s)
+	}
+	return "`" + s + "`"
+}
+
+func utf8Printable(s string) bool {
+	for _, r := range s {
+		if r == unicode.ReplacementChar || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+// Code generated by envgen from config.yaml. DO NOT EDIT.
+
+package config
+
+import (
+	"net/url"
+	"time"
+
+	"code.sajari.com/env"
+)
+
+// Config is the configuration of my-service.
+type Config struct {
+	// Listen is read from LISTEN: address to listen on.
+	Listen *string
+
+	// Level is read from LOG_LEVEL: log level.
+	Level *env.Live[string]
+
+	// APIURL is read from API_URL: base URL of the API.
+	APIURL *url.URL
+
+	// APIKey is read from API_KEY: key for the API.
+	APIKey *string
+
+	// Timeout is read from TIMEOUT: request timeout.
+	Timeout *time.Duration
+
+	// Workers is read from WORKERS: number of workers.
+	Workers *int
+
+	// Region is read from REGION: deployment region.
+	Region *string
+
+	// Deprecated: no longer used.
+	LegacyMode *bool
+}
+
+// RegisterConfig defines the variables of Config in vs (or env.CmdVar if vs
+// is nil), and returns the Config which holds their values once vs is
+// parsed. The names are prefixed by vs as usual.
+func RegisterConfig(vs *env.VarSet) *Config {
+	if vs == nil {
+		vs = env.CmdVar
+	}
+	return &Config{
+		Listen:     vs.BindAddr("LISTEN", "address to listen on", env.Default(":8080")),
+		Level:      env.NewLive(vs.String, "LOG_LEVEL", "log level", env.Default("info"), env.OneOf("debug", "info", "warn", "error")),
+		APIURL:     vs.URL("API_URL", "base URL of the API", env.Example("https://api.example.com")),
+		APIKey:     vs.String("API_KEY", "key for the API", env.Aliases("KEY"), env.DeprecatedAlias("OLD_KEY", "renamed to API_KEY"), env.Secret()),
+		Timeout:    vs.Duration("TIMEOUT", "request timeout", env.Default("10s")),
+		Workers:    vs.Int("WORKERS", "number of workers", env.Default("4"), env.Min(1), env.Max(64)),
+		Region:     vs.String("REGION", "deployment region", env.Optional(), env.Pattern(`^[a-z]+-[a-z]+-[0-9]$`)),
+		LegacyMode: vs.Bool("LEGACY_MODE", "", env.Deprecated("no longer used"), env.Optional()),
+	}
+}
@@ -0,0 +1,15 @@
+// Command envlint reports common mistakes in the use of the env package (see
+// the envlint package).  It can be run directly, or by go vet:
+//
+//	go vet -vettool=$(which envlint) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"code.sajari.com/env/tools/envlint"
+)
+
+func main() {
+	singlechecker.Main(envlint.Analyzer)
+}
@@ -0,0 +1,708 @@
+// Package envlint defines an Analyzer which reports common mistakes in the
+// use of the env package:
+//
+//   - variables defined with non-constant names, which can't be documented
+//     or checked statically (see cmd/envcheck)
+//   - names which aren't upper case letters, digits and underscores, not
+//     starting with a digit
+//   - dereferencing the pointer returned by a definition such as env.String
+//     before the variables are parsed, which reads the zero value: in
+//     package-level variable initialisers, init, main before it calls
+//     Parse, and functions defining the variable before they call Parse
+//   - os.Getenv and os.LookupEnv calls for variables which are also defined
+//     in a VarSet, which bypass its defaults, aliases and validation
+//   - the same variable defined on env.CmdVar by more than one package, so
+//     that only one of the definitions is used
+//
+// Names are not required to be constant in tests.  Other findings can be
+// suppressed by an //envlint:ignore comment on the line reported, or the line
+// before it, such as for code which defines variables described by a schema.
+//
+// The analyzer can be run by go vet using cmd/envlint:
+//
+//	go vet -vettool=$(which envlint) ./...
+package envlint
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const doc = `report common mistakes using code.sajari.com/env
+
+Reports variables defined with non-constant or invalid names, values
+dereferenced before they are parsed, os.Getenv calls for variables which
+are also defined in a VarSet, and variables defined on env.CmdVar by more
+than one package.`
+
+// Analyzer reports common mistakes in the use of the env package.
+var Analyzer = &analysis.Analyzer{
+	Name:      "envlint",
+	Doc:       doc,
+	Run:       run,
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	FactTypes: []analysis.Fact{new(cmdVarsFact)},
+}
+
+// Import paths of the packages checked.
+const (
+	envPath    = "code.sajari.com/env"
+	envsvcPath = "code.sajari.com/env/envsvc"
+)
+
+// cmdVarsFact is exported for packages which define variables on
+// env.CmdVar, to detect definitions of the same variable in other packages.
+type cmdVarsFact struct {
+	Vars []cmdVar
+}
+
+func (*cmdVarsFact) AFact() {}
+
+func (f *cmdVarsFact) String() string {
+	names := make([]string, len(f.Vars))
+	for i, v := range f.Vars {
+		names[i] = v.Name
+	}
+	return "cmdVars(" + strings.Join(names, ", ") + ")"
+}
+
+// cmdVar is a variable defined on env.CmdVar.
+type cmdVar struct {
+	Name string // name without the command prefix, including those of any subsets
+	Pkg  string // import path of the package defining it
+	Pos  string // position of the definition
+}
+
+// varDefs are the names of the functions and methods which define
+// variables, and the index of the name argument.
+var varDefs = map[string]int{
+	"String": 0, "StringRequired": 0, "Int": 0, "Int64": 0, "Float32": 0,
+	"Float64": 0, "Bool": 0, "Duration": 0, "BindAddr": 0, "DialAddr": 0,
+	"URL": 0, "Path": 0, "Var": 1,
+}
+
+// validName matches portable environment variable names.
+var validName = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// majorVersion matches the major version suffix of an import path.
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// varSet describes a variable set, as far as it can be determined
+// statically.  As in env, the prefixes of subsets are derived from their
+// parents when names are needed, after any calls to SetPrefix and
+// SetSeparator.
+type varSet struct {
+	cmd    bool    // if true, the set is env.CmdVar or one of its subsets
+	parent *varSet // nil for env.CmdVar and sets created by NewVarSet
+	name   string  // name of a subset
+	prefix string  // prefix of a set without a parent, or set by SetPrefix
+	fixed  bool    // if true, prefix was set by SetPrefix
+	sep    string  // set by SetSeparator, or "" to use the parent's
+	subs   map[string]*varSet
+}
+
+// sub returns the subset of vs with the given name.
+func (vs *varSet) sub(name string) *varSet {
+	if s, ok := vs.subs[name]; ok {
+		return s
+	}
+	s := &varSet{cmd: vs.cmd, parent: vs, name: name}
+	if vs.subs == nil {
+		vs.subs = make(map[string]*varSet)
+	}
+	vs.subs[name] = s
+	return s
+}
+
+// separator returns the separator between the prefix of vs and names.
+func (vs *varSet) separator() string {
+	for s := vs; s != nil; s = s.parent {
+		if s.sep != "" {
+			return s.sep
+		}
+	}
+	return "_"
+}
+
+// prefixOf returns the prefix of vs, relative to env.CmdVar if vs.cmd.
+func (vs *varSet) prefixOf() string {
+	if vs.parent == nil || vs.fixed {
+		return vs.prefix
+	}
+	return vs.parent.join(prefixName(vs.name))
+}
+
+// join returns the full name of key in vs, relative to env.CmdVar if vs.cmd.
+func (vs *varSet) join(key string) string {
+	prefix := vs.prefixOf()
+	if prefix == "" {
+		return key
+	}
+	return prefix + vs.separator() + key
+}
+
+// definition is a call defining a variable.
+type definition struct {
+	call *ast.CallExpr
+	name string  // constant name argument
+	set  *varSet // nil if unknown
+	live bool    // if true, call is to env.NewLive, which doesn't return a pointer to the value
+}
+
+type checker struct {
+	pass    *analysis.Pass
+	cmd     *varSet // env.CmdVar
+	sets    map[types.Object]*varSet
+	defs    []definition
+	ignored map[string]map[int]bool // lines with //envlint:ignore comments, by file
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if pass.Pkg.Path() == envPath {
+		return nil, nil
+	}
+	c := &checker{
+		pass:    pass,
+		cmd:     &varSet{cmd: true},
+		sets:    make(map[types.Object]*varSet),
+		ignored: make(map[string]map[int]bool),
+	}
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	for _, f := range pass.Files {
+		for _, g := range f.Comments {
+			for _, com := range g.List {
+				if strings.HasPrefix(com.Text, "//envlint:ignore") {
+					p := pass.Fset.Position(com.Pos())
+					if c.ignored[p.Filename] == nil {
+						c.ignored[p.Filename] = make(map[int]bool)
+					}
+					c.ignored[p.Filename][p.Line] = true
+				}
+			}
+		}
+	}
+
+	c.bindSets(ins)
+	c.checkDefinitions(ins)
+	c.checkDerefs()
+	c.checkGetenv(ins)
+	c.checkCmdVars()
+	return nil, nil
+}
+
+// reportf reports a problem at pos, unless it is suppressed by an
+// //envlint:ignore comment.
+func (c *checker) reportf(pos token.Pos, format string, args ...interface{}) {
+	p := c.pass.Fset.Position(pos)
+	if lines := c.ignored[p.Filename]; lines[p.Line] || lines[p.Line-1] {
+		return
+	}
+	c.pass.Reportf(pos, format, args...)
+}
+
+// callee returns the function or method in env called by call, and whether
+// it is a method of VarSet.
+func (c *checker) callee(call *ast.CallExpr, pkgPath string) (*types.Func, bool) {
+	fn, _ := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != pkgPath {
+		return nil, false
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return fn, false
+	}
+	t := recv.Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if n, ok := t.(*types.Named); !ok || n.Obj().Name() != "VarSet" {
+		return nil, false
+	}
+	return fn, true
+}
+
+// bindSets records the variables in the package which are assigned
+// variable sets, and the prefixes set on them by SetPrefix.
+func (c *checker) bindSets(ins *inspector.Inspector) {
+	var assigns [][2]ast.Expr
+	ins.Preorder([]ast.Node{(*ast.AssignStmt)(nil), (*ast.ValueSpec)(nil)}, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) == len(n.Rhs) {
+				for i := range n.Lhs {
+					assigns = append(assigns, [2]ast.Expr{n.Lhs[i], n.Rhs[i]})
+				}
+			}
+		case *ast.ValueSpec:
+			if len(n.Names) == len(n.Values) {
+				for i := range n.Names {
+					assigns = append(assigns, [2]ast.Expr{n.Names[i], n.Values[i]})
+				}
+			}
+		}
+	})
+
+	// Repeat until nothing changes, so that the order of declarations
+	// doesn't matter.
+	for changed := true; changed; {
+		changed = false
+		for _, a := range assigns {
+			obj := c.object(a[0])
+			if obj == nil || c.sets[obj] != nil {
+				continue
+			}
+			if vs := c.evalSet(a[1]); vs != nil {
+				c.sets[obj] = vs
+				changed = true
+			}
+		}
+	}
+
+	ins.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn, method := c.callee(call, envPath)
+		if !method || fn.Name() != "SetPrefix" && fn.Name() != "SetSeparator" {
+			return
+		}
+		vs := c.evalSet(call.Fun.(*ast.SelectorExpr).X)
+		arg, ok := c.constString(call.Args[0])
+		switch {
+		case !ok || vs == nil:
+		case fn.Name() == "SetSeparator":
+			vs.sep = arg
+		case !vs.cmd:
+			// The prefix of env.CmdVar depends on the command, so
+			// names in it are relative to it.
+			vs.prefix, vs.fixed = arg, true
+		}
+	})
+}
+
+// object returns the variable referred to by e, or nil.
+func (c *checker) object(e ast.Expr) types.Object {
+	var id *ast.Ident
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.SelectorExpr:
+		id = e.Sel
+	default:
+		return nil
+	}
+	obj := c.pass.TypesInfo.ObjectOf(id)
+	if _, ok := obj.(*types.Var); !ok {
+		return nil
+	}
+	return obj
+}
+
+// evalSet returns the variable set e evaluates to, or nil if it isn't
+// known.
+func (c *checker) evalSet(e ast.Expr) *varSet {
+	e = ast.Unparen(e)
+	call, ok := e.(*ast.CallExpr)
+	if !ok {
+		obj := c.object(e)
+		if obj == nil {
+			return nil
+		}
+		if obj.Pkg() != nil && obj.Pkg().Path() == envPath && obj.Name() == "CmdVar" {
+			return c.cmd
+		}
+		return c.sets[obj]
+	}
+
+	fn, method := c.callee(call, envPath)
+	if fn == nil || len(call.Args) != 1 {
+		return nil
+	}
+	name, ok := c.constString(call.Args[0])
+	if !ok {
+		return nil
+	}
+	switch {
+	case fn.Name() == "NewVarSet" && !method:
+		return &varSet{prefix: prefixName(name)}
+	case fn.Name() == "Sub" && !method:
+		return c.cmd.sub(name)
+	case fn.Name() == "Sub":
+		parent := c.evalSet(call.Fun.(*ast.SelectorExpr).X)
+		if parent == nil {
+			return nil
+		}
+		return parent.sub(name)
+	}
+	return nil
+}
+
+// prefixName returns the prefix derived from the name of a set, as
+// env.NewVarSet does.
+func prefixName(name string) string {
+	return strings.ReplaceAll(strings.ToUpper(name), "-", "_")
+}
+
+func (c *checker) constString(e ast.Expr) (string, bool) {
+	tv, ok := c.pass.TypesInfo.Types[e]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// checkDefinitions reports definitions with non-constant or invalid names,
+// and records the others.
+func (c *checker) checkDefinitions(ins *inspector.Inspector) {
+	ins.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn, method := c.callee(call, envPath)
+		if fn == nil {
+			return
+		}
+		args, live := call.Args, false
+		recv := ast.Expr(nil)
+		if method {
+			recv = call.Fun.(*ast.SelectorExpr).X
+		}
+		if fn.Name() == "NewLive" && !method && len(args) > 0 {
+			// The first argument is the function or method defining
+			// the variable, such as vs.String.
+			if fn, method = c.envFunc(args[0]); fn == nil || fn.Name() == "Var" {
+				return
+			}
+			recv = nil
+			if method {
+				recv = ast.Unparen(args[0]).(*ast.SelectorExpr).X
+			}
+			args, live = args[1:], true
+		}
+		i, ok := varDefs[fn.Name()]
+		if !ok || len(args) <= i {
+			return
+		}
+		if fn.Name() == "Var" && !method {
+			return
+		}
+
+		arg := args[i]
+		name, ok := c.constString(arg)
+		if !ok {
+			if !c.isTest(arg.Pos()) {
+				// Tests often define variables in loops.
+				c.reportf(arg.Pos(), "env variable name should be constant")
+			}
+			return
+		}
+		switch {
+		case strings.ToUpper(name) != name && validName.MatchString(strings.ToUpper(name)):
+			c.reportf(arg.Pos(), "env variable name %q should be upper case", name)
+		case !validName.MatchString(name):
+			c.reportf(arg.Pos(), "invalid env variable name %q: use upper case letters, digits and underscores", name)
+		}
+
+		d := definition{call: call, name: name, live: live}
+		if recv != nil {
+			d.set = c.evalSet(recv)
+		} else {
+			d.set = c.cmd
+		}
+		c.defs = append(c.defs, d)
+	})
+}
+
+// envFunc returns the function or method of VarSet in env referred to by e,
+// such as env.Int or vs.Int, and whether it is a method.
+func (c *checker) envFunc(e ast.Expr) (*types.Func, bool) {
+	var id *ast.Ident
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.SelectorExpr:
+		id = e.Sel
+	default:
+		return nil, false
+	}
+	fn, _ := c.pass.TypesInfo.Uses[id].(*types.Func)
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != envPath {
+		return nil, false
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return fn, false
+	}
+	t := recv.Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if n, ok := t.(*types.Named); !ok || n.Obj().Name() != "VarSet" {
+		return nil, false
+	}
+	return fn, true
+}
+
+// checkDerefs reports pointers returned by definitions which are
+// dereferenced before the variables are parsed: in package-level variable
+// initialisers, and in functions before any call to Parse.
+func (c *checker) checkDerefs() {
+	// Variables assigned the result of a definition.
+	defined := make(map[types.Object]bool)
+	calls := make(map[*ast.CallExpr]bool)
+	for _, d := range c.defs {
+		if !d.live {
+			calls[d.call] = true
+		}
+	}
+	for _, f := range c.pass.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if len(n.Lhs) == len(n.Rhs) {
+					for i, rhs := range n.Rhs {
+						if call, ok := ast.Unparen(rhs).(*ast.CallExpr); ok && calls[call] {
+							if obj := c.object(n.Lhs[i]); obj != nil {
+								defined[obj] = true
+							}
+						}
+					}
+				}
+			case *ast.ValueSpec:
+				if len(n.Names) == len(n.Values) {
+					for i, rhs := range n.Values {
+						if call, ok := ast.Unparen(rhs).(*ast.CallExpr); ok && calls[call] {
+							if obj := c.object(n.Names[i]); obj != nil {
+								defined[obj] = true
+							}
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	// deref reports whether e dereferences a defined variable or the result
+	// of a definition.
+	deref := func(e *ast.StarExpr) bool {
+		x := ast.Unparen(e.X)
+		if call, ok := x.(*ast.CallExpr); ok {
+			return calls[call]
+		}
+		obj := c.object(x)
+		return obj != nil && defined[obj]
+	}
+	report := func(e *ast.StarExpr) {
+		c.reportf(e.Pos(), "env variable dereferenced before it is parsed")
+	}
+
+	for _, f := range c.pass.Files {
+		if c.isTest(f.Pos()) {
+			// Tests may check values before they are parsed.
+			continue
+		}
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				if decl.Tok != token.VAR {
+					continue
+				}
+				// Package-level variables are initialised before main.
+				ast.Inspect(decl, func(n ast.Node) bool {
+					if _, ok := n.(*ast.FuncLit); ok {
+						return false
+					}
+					if e, ok := n.(*ast.StarExpr); ok && c.isValue(e) && deref(e) {
+						report(e)
+					}
+					return true
+				})
+			case *ast.FuncDecl:
+				if decl.Body != nil {
+					c.checkFuncDerefs(decl, deref, report)
+				}
+			}
+		}
+	}
+}
+
+// checkFuncDerefs reports dereferences in the body of fn which precede the
+// first call to Parse in it.  Other functions may be called after the
+// variables are parsed elsewhere, so all dereferences are only checked in
+// init, which runs before main, and in main if it calls Parse; in other
+// functions, only variables defined in the function are checked.  Function
+// literals are not checked, as they may be called after parsing.
+func (c *checker) checkFuncDerefs(fn *ast.FuncDecl, deref func(*ast.StarExpr) bool, report func(*ast.StarExpr)) {
+	body := fn.Body
+	parsed := token.NoPos
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && c.isParse(call) && (!parsed.IsValid() || call.Pos() < parsed) {
+			parsed = call.Pos()
+		}
+		_, lit := n.(*ast.FuncLit)
+		return !lit
+	})
+
+	all := false
+	if fn.Recv == nil {
+		switch fn.Name.Name {
+		case "init":
+			all = true
+		case "main":
+			all = c.pass.Pkg.Name() == "main" && parsed.IsValid()
+		}
+	}
+	// local reports whether e dereferences the result of a definition in
+	// body, or a variable declared in it.
+	local := func(e *ast.StarExpr) bool {
+		x := ast.Unparen(e.X)
+		if _, ok := x.(*ast.CallExpr); ok {
+			return true
+		}
+		obj := c.object(x)
+		return obj != nil && body.Pos() <= obj.Pos() && obj.Pos() < body.End()
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.StarExpr:
+			if c.isValue(n) && deref(n) && (all || local(n)) && (!parsed.IsValid() || n.Pos() < parsed) {
+				report(n)
+			}
+		}
+		return true
+	})
+}
+
+// isValue reports whether e is a dereference, rather than a pointer type.
+func (c *checker) isValue(e *ast.StarExpr) bool {
+	tv, ok := c.pass.TypesInfo.Types[e]
+	return ok && tv.IsValue()
+}
+
+// isTest reports whether pos is in a test file.
+func (c *checker) isTest(pos token.Pos) bool {
+	return strings.HasSuffix(c.pass.Fset.File(pos).Name(), "_test.go")
+}
+
+// isParse reports whether call parses variables, using env.Parse,
+// VarSet.Parse or one of the Parse functions in envsvc.
+func (c *checker) isParse(call *ast.CallExpr) bool {
+	if fn, _ := c.callee(call, envPath); fn != nil && fn.Name() == "Parse" {
+		return true
+	}
+	fn, _ := c.callee(call, envsvcPath)
+	return fn != nil && strings.HasPrefix(fn.Name(), "Parse")
+}
+
+// checkGetenv reports calls to os.Getenv and os.LookupEnv for variables
+// defined in a VarSet in the package, or on env.CmdVar by the command.
+func (c *checker) checkGetenv(ins *inspector.Inspector) {
+	names := make(map[string]bool)
+	for _, d := range c.defs {
+		switch {
+		case d.set == nil:
+		case !d.set.cmd:
+			names[d.set.join(d.name)] = true
+		case c.pass.Pkg.Name() == "main":
+			names[c.cmdName(d.set.join(d.name))] = true
+		}
+	}
+	if c.pass.Pkg.Name() == "main" {
+		for _, v := range c.importedCmdVars() {
+			names[c.cmdName(v.Name)] = true
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	ins.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn, _ := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+		if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != "os" || fn.Name() != "Getenv" && fn.Name() != "LookupEnv" {
+			return
+		}
+		if name, ok := c.constString(call.Args[0]); ok && names[name] {
+			c.reportf(call.Pos(), "os.%v(%q) reads a variable defined using env", fn.Name(), name)
+		}
+	})
+}
+
+// cmdName returns the full name of the variable with the given name relative
+// to env.CmdVar, for the command built from the package.  The prefix of
+// env.CmdVar is derived from the name of the binary (see env.CmdName).
+func (c *checker) cmdName(name string) string {
+	cmd := path.Base(c.pass.Pkg.Path())
+	if majorVersion.MatchString(cmd) {
+		cmd = path.Base(path.Dir(c.pass.Pkg.Path()))
+	}
+	return prefixName(cmd) + c.cmd.separator() + name
+}
+
+// importedCmdVars returns the variables defined on env.CmdVar by the
+// packages imported (directly or indirectly) by the package.
+func (c *checker) importedCmdVars() []cmdVar {
+	var vars []cmdVar
+	for _, f := range c.pass.AllPackageFacts() {
+		if f.Package != c.pass.Pkg {
+			vars = append(vars, f.Fact.(*cmdVarsFact).Vars...)
+		}
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		if vars[i].Pkg != vars[j].Pkg {
+			return vars[i].Pkg < vars[j].Pkg
+		}
+		return vars[i].Pos < vars[j].Pos
+	})
+	return vars
+}
+
+// checkCmdVars reports variables defined on env.CmdVar more than once, and
+// exports the definitions in the package as a fact.  Definitions in two
+// imported packages are reported in main packages, which are the first to
+// import both.
+func (c *checker) checkCmdVars() {
+	seen := make(map[string]cmdVar)
+	for _, v := range c.importedCmdVars() {
+		if prev, ok := seen[v.Name]; ok && c.pass.Pkg.Name() == "main" {
+			c.reportf(c.pass.Files[0].Name.Pos(), "env variable %v defined on env.CmdVar by both %v and %v", v.Name, prev.Pkg, v.Pkg)
+		}
+		seen[v.Name] = v
+	}
+
+	var fact cmdVarsFact
+	for _, d := range c.defs {
+		if d.set == nil || !d.set.cmd || c.isTest(d.call.Pos()) {
+			// Test files aren't built into commands, so their
+			// definitions can't conflict with those of other packages.
+			continue
+		}
+		v := cmdVar{
+			Name: d.set.join(d.name),
+			Pkg:  c.pass.Pkg.Path(),
+			Pos:  c.pass.Fset.Position(d.call.Pos()).String(),
+		}
+		if prev, ok := seen[v.Name]; ok {
+			// Only the first definition is exported, so that packages
+			// importing this one don't report it again.
+			c.reportf(d.call.Pos(), "env variable %v already defined on env.CmdVar at %v", v.Name, prev.Pos)
+			continue
+		}
+		seen[v.Name] = v
+		fact.Vars = append(fact.Vars, v)
+	}
+	if len(fact.Vars) > 0 {
+		c.pass.ExportPackageFact(&fact)
+	}
+}
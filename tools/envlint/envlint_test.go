@@ -0,0 +1,13 @@
+package envlint_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"code.sajari.com/env/tools/envlint"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), envlint.Analyzer, "./a", "./b", "./c", "./cmd/app")
+}
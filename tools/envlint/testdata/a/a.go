@@ -0,0 +1,81 @@
+package a // want package:"cmdVars\\(SHARED, LOCAL, CACHE_TTL\\)"
+
+import (
+	"os"
+
+	"code.sajari.com/env"
+)
+
+var vs = env.NewVarSet("db")
+
+var (
+	host = vs.String("HOST", "database host")
+	port = vs.Int("port", "database port")        // want `env variable name "port" should be upper case`
+	mode = vs.String("MODE-X", "database mode")   // want `invalid env variable name "MODE-X": use upper case letters, digits and underscores`
+	num  = vs.String("1ST", "first")              // want `invalid env variable name "1ST"`
+	addr = *vs.String("ADDR", "database address") // want `env variable dereferenced before it is parsed`
+	size = *port                                  // want `env variable dereferenced before it is parsed`
+
+	Shared = env.String("SHARED", "defined in a and b")
+	Local  = env.String("LOCAL", "defined twice in a")
+	cache  = env.Sub("cache")
+	TTL    = cache.Int("TTL", "cache entry lifetime")
+)
+
+func define(name string) {
+	vs.String(name, "dynamic") // want `env variable name should be constant`
+	vs.Var(nil, "VALUE", "value")
+	vs.Var(nil, "value", "value") // want `env variable name "value" should be upper case`
+	env.String("LOCAL", "again")  // want `env variable LOCAL already defined on env.CmdVar at .*a.go:20:.*`
+}
+
+func parse() {
+	timeout := vs.Int("TIMEOUT", "timeout")
+	_ = *timeout                        // want `env variable dereferenced before it is parsed`
+	f := func() int { return *timeout } // may be called after parsing
+	vs.Parse(nil)
+	_ = *timeout
+	_ = *host
+	_ = f
+	var p *int // a pointer type, not a dereference
+	_ = p
+}
+
+func getenv() {
+	_ = os.Getenv("DB_HOST")          // want `os.Getenv\("DB_HOST"\) reads a variable defined using env`
+	_, _ = os.LookupEnv("DB_TIMEOUT") // want `os.LookupEnv\("DB_TIMEOUT"\) reads a variable defined using env`
+	_ = os.Getenv("HOST")
+	_ = os.Getenv("SHARED") // CmdVar prefix unknown outside main
+}
+
+func prefixed() {
+	other := env.NewVarSet("other")
+	other.SetPrefix("OTHER_SERVICE")
+	other.String("KEY", "key")
+	_ = os.Getenv("OTHER_SERVICE_KEY") // want `os.Getenv\("OTHER_SERVICE_KEY"\) reads a variable defined using env`
+}
+
+func separator() {
+	dots := env.NewVarSet("dots")
+	dots.SetSeparator(".")
+	dots.Sub("sub").String("KEY", "key")
+	_ = os.Getenv("DOTS.SUB.KEY") // want `os.Getenv\("DOTS.SUB.KEY"\) reads a variable defined using env`
+	_ = os.Getenv("DOTS_SUB_KEY")
+}
+
+func live() {
+	level := env.NewLive(vs.String, "LEVEL", "log level")
+	env.NewLive(vs.Int, "limit", "limit") // want `env variable name "limit" should be upper case`
+	_ = level.Load()
+	_ = os.Getenv("DB_LEVEL") // want `os.Getenv\("DB_LEVEL"\) reads a variable defined using env`
+}
+
+func ignored(name string) {
+	vs.String(name, "defined from a schema") //envlint:ignore
+
+	//envlint:ignore names are checked by the schema
+	vs.String(name, "defined from a schema")
+}
+
+// hostName is called after the variables are parsed.
+func hostName() string { return *host }
@@ -0,0 +1,9 @@
+package a
+
+import "testing"
+
+func TestDefine(t *testing.T) {
+	for _, name := range []string{"A", "B"} {
+		vs.String(name, "defined in a loop")
+	}
+}
@@ -0,0 +1,5 @@
+package b // want package:"cmdVars\\(SHARED\\)"
+
+import "code.sajari.com/env"
+
+var Shared = env.String("SHARED", "defined in a and b")
@@ -0,0 +1,13 @@
+package c
+
+import (
+	"code.sajari.com/env"
+
+	"lint/a"
+)
+
+var (
+	Shared = env.String("SHARED", "also defined in a")        // want `env variable SHARED already defined on env.CmdVar at .*a.go:.*`
+	TTL    = env.Sub("cache").Int("TTL", "also defined in a") // want `env variable CACHE_TTL already defined on env.CmdVar at .*a.go:.*`
+	_      = a.Shared
+)
@@ -0,0 +1,33 @@
+package main // want package:"cmdVars\\(LEVEL\\)" `env variable SHARED defined on env.CmdVar by both lint/a and lint/b`
+
+import (
+	"os"
+
+	"code.sajari.com/env"
+	"code.sajari.com/env/envsvc"
+
+	"lint/a"
+	"lint/b"
+)
+
+var level = env.String("LEVEL", "log level")
+
+func main() {
+	_ = *level // want `env variable dereferenced before it is parsed`
+	envsvc.Parse()
+	_ = *level
+	_ = os.Getenv("APP_LEVEL")     // want `os.Getenv\("APP_LEVEL"\) reads a variable defined using env`
+	_ = os.Getenv("APP_CACHE_TTL") // want `os.Getenv\("APP_CACHE_TTL"\) reads a variable defined using env`
+	_, _ = a.Shared, b.Shared
+}
+
+func init() {
+	_ = *level // want `env variable dereferenced before it is parsed`
+}
+
+// Helpers and methods reading variables are called after main parses them.
+func logLevel() string { return *level }
+
+type server struct{}
+
+func (server) level() string { return *level }
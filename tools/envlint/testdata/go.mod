@@ -0,0 +1,10 @@
+module lint
+
+go 1.22
+
+require code.sajari.com/env v0.0.0-00010101000000-000000000000
+
+require gopkg.in/yaml.v3 v3.0.1 // indirect
+
+// The analyzer is tested against the real env package.
+replace code.sajari.com/env => ../../..
@@ -0,0 +1,17 @@
+module code.sajari.com/env/tools
+
+go 1.22.0
+
+require (
+	code.sajari.com/env v0.0.0-00010101000000-000000000000
+	golang.org/x/tools v0.26.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/mod v0.21.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+)
+
+// The tools are developed alongside the env package.
+replace code.sajari.com/env => ../
@@ -0,0 +1,94 @@
+package env
+
+import "fmt"
+
+// UnclaimedVar is an environment variable which has the prefix of a VarSet,
+// but is not read by any of its variables.  These are usually typos.
+type UnclaimedVar struct {
+	Name       string
+	Suggestion string // most similar variable name, if any are similar
+}
+
+func (u UnclaimedVar) String() string {
+	if u.Suggestion != "" {
+		return fmt.Sprintf("unknown env %v (did you mean %v?)", u.Name, u.Suggestion)
+	}
+	return fmt.Sprintf("unknown env %v", u.Name)
+}
+
+// Unclaimed returns the variables in g which have the prefix of the set, but
+// are not read by any of its variables (or their aliases), along with the
+// most similar variable name as a suggestion.  Nothing is returned if g is
+// not a Lister, or the set has no prefix.
+func (v *VarSet) Unclaimed(g Getter) []UnclaimedVar {
+	names := v.unknownNames(g)
+	if len(names) == 0 {
+		return nil
+	}
+
+	out := make([]UnclaimedVar, 0, len(names))
+	for _, name := range names {
+		out = append(out, UnclaimedVar{Name: name, Suggestion: v.suggest(name)})
+	}
+	return out
+}
+
+// SetStrict sets whether Parse returns an error for each variable reported
+// by Unclaimed.
+func (v *VarSet) SetStrict(strict bool) {
+	v.strict = strict
+}
+
+// Strict reports whether Parse returns errors for unclaimed variables.
+func (v *VarSet) Strict() bool {
+	return v.strict
+}
+
+// suggest returns the name of the variable (or alias) in the set most
+// similar to name, or "" if none are similar enough.
+func (v *VarSet) suggest(name string) string {
+	// Allow one edit for every three characters after the prefix.
+	limit := max(1, (len(name)-len(v.prefix)-len(v.sep))/3)
+
+	best, bestDist := "", limit+1
+	for _, x := range v.vars {
+		if d := editDistance(name, x.Name); d < bestDist {
+			best, bestDist = x.Name, d
+		}
+		for _, a := range x.Aliases {
+			if d := editDistance(name, a.Name); d < bestDist {
+				best, bestDist = a.Name, d
+			}
+		}
+	}
+	return best
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b: the number of insertions, deletions, substitutions and transpositions of
+// adjacent bytes needed to change a into b.
+func editDistance(a, b string) int {
+	// d[i][j] is the distance between a[:i] and b[:j].
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,67 @@
+package env_test
+
+import (
+	"reflect"
+	"testing"
+
+	"code.sajari.com/env"
+)
+
+func TestUnclaimed(t *testing.T) {
+	vs := env.NewVarSet("mysvc")
+	vs.Int("WORKERS", "workers test")
+	vs.String("LISTEN", "listen test", env.DeprecatedAlias("MYSVC_BIND", "renamed"))
+	vs.Sub("DB").String("HOST", "host test")
+
+	g := env.Map{
+		"MYSVC_WORKERS":     "4",
+		"MYSVC_WORKRES":     "4",
+		"MYSVC_BNID":        ":80",
+		"MYSVC_DB_HSOT":     "db",
+		"MYSVC_LISTEN":      ":80",
+		"MYSVC_DB_HOST":     "db",
+		"MYSVC_SOMETHING":   "1",
+		"OTHERSVC_WORKRES":  "4",
+		"MYSVCWORKERS":      "4",
+		"MYSVC_DB_PASSWORD": "x",
+	}
+
+	want := []env.UnclaimedVar{
+		{Name: "MYSVC_BNID", Suggestion: "MYSVC_BIND"},
+		{Name: "MYSVC_DB_HSOT", Suggestion: "MYSVC_DB_HOST"},
+		{Name: "MYSVC_DB_PASSWORD"},
+		{Name: "MYSVC_SOMETHING"},
+		{Name: "MYSVC_WORKRES", Suggestion: "MYSVC_WORKERS"},
+	}
+	if got := vs.Unclaimed(g); !reflect.DeepEqual(got, want) {
+		t.Errorf("vs.Unclaimed() = %v, expected %v", got, want)
+	}
+
+	if err := vs.Parse(g); err != nil {
+		t.Errorf("vs.Parse() = %v, expected nil error", err)
+	}
+
+	vs.SetStrict(true)
+	err := vs.Parse(g)
+	es, ok := err.(env.Errors)
+	if !ok || len(es) != len(want) {
+		t.Fatalf("vs.Parse() = %v, expected %d errors", err, len(want))
+	}
+	if got, want := es[4].Error(), "unknown env MYSVC_WORKRES (did you mean MYSVC_WORKERS?)"; got != want {
+		t.Errorf("vs.Parse() error = %q, expected %q", got, want)
+	}
+
+	// Getters which can't list their variables are not checked.
+	if err := vs.Parse(testGetter(g)); err != nil {
+		t.Errorf("vs.Parse() = %v, expected nil error", err)
+	}
+}
+
+func TestUnclaimedNoPrefix(t *testing.T) {
+	vs := env.NewVarSet("")
+	vs.String("NAME", "name test")
+
+	if got := vs.Unclaimed(env.Map{"NAME": "x", "NMAE": "y"}); got != nil {
+		t.Errorf("vs.Unclaimed() = %v, expected nil", got)
+	}
+}